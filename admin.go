@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
+)
+
+type (
+	popularity interface {
+		Top(int) []weather.LocationCount
+	}
+
+	expirer interface {
+		ExpiresIn(string) (time.Duration, bool)
+	}
+
+	adminCache interface {
+		expirer
+		Entries() []weather.CacheEntry
+		Purge(string)
+		Refresh(string) (*weather.Conditions, error)
+	}
+
+	monitor interface {
+		Calls() int
+		Errors() []weather.ForecastError
+	}
+
+	keyHealth interface {
+		Health() []worldweatheronline.KeyHealth
+	}
+
+	trackedLocation struct {
+		weather.LocationCount
+		Cached    bool    `json:"cached"`
+		ExpiresIn float64 `json:"expires_in_seconds,omitempty"`
+	}
+)
+
+// locationsHandler lists the topN tracked locations as JSON together
+// with the time their cache entries stay fresh. A negative topN lists
+// every tracked location.
+func locationsHandler(p popularity, c expirer, topN int) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		top := p.Top(topN)
+		locations := make([]trackedLocation, len(top))
+
+		for i, lc := range top {
+			locations[i].LocationCount = lc
+			if d, ok := c.ExpiresIn(lc.Location); ok {
+				locations[i].Cached = true
+				locations[i].ExpiresIn = d.Seconds()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(locations); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// adminHandler renders the admin dashboard showing the upstream usage,
+// the health and quota of the API keys, the cache contents, the recent upstream errors and the most
+// requested locations.
+func adminHandler(rdr renderer, c adminCache, m monitor, k keyHealth, p popularity, topN int) func(w http.ResponseWriter, r *http.Request) {
+	tmpl := rdr.Template("admin.tmpl")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := rdr.RenderTemplate(w, tmpl, map[string]interface{}{
+			"calls":   m.Calls(),
+			"keys":    k.Health(),
+			"errors":  m.Errors(),
+			"entries": c.Entries(),
+			"popular": p.Top(topN),
+			"flash":   r.URL.Query().Get("flash"),
+		}); err != nil {
+			renderError(w, r, rdr, err)
+		}
+	}
+}
+
+// purgeHandler removes the posted location from the cache and redirects
+// back to the dashboard
+func purgeHandler(c adminCache) func(w http.ResponseWriter, r *http.Request) {
+	return adminAction(func(location string) string {
+		c.Purge(location)
+		return "Purged " + location
+	})
+}
+
+// refreshHandler fetches the posted location from upstream and redirects
+// back to the dashboard
+func refreshHandler(c adminCache) func(w http.ResponseWriter, r *http.Request) {
+	return adminAction(func(location string) string {
+		if _, err := c.Refresh(location); err != nil {
+			return "Failed to refresh " + location + ": " + err.Error()
+		}
+		return "Refreshed " + location
+	})
+}
+
+func adminAction(action func(location string) string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		location := r.PostFormValue("location")
+		if location == "" {
+			http.Error(w, "missing location", http.StatusBadRequest)
+			return
+		}
+
+		flash := url.Values{"flash": []string{action(location)}}
+		http.Redirect(w, r, "/admin?"+flash.Encode(), http.StatusSeeOther)
+	}
+}
+
+// requireToken only lets requests through that carry the admin token,
+// either as a bearer token or as the password of basic auth. Posts from
+// other origins are rejected since browsers resend basic auth with them.
+func requireToken(token string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !validToken(token, r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if origin := r.Header.Get("Origin"); r.Method == http.MethodPost && origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				http.Error(w, "cross origin request", http.StatusForbidden)
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+func validToken(token string, r *http.Request) bool {
+	given := ""
+	if _, password, ok := r.BasicAuth(); ok {
+		given = password
+	} else if auth := r.Header.Get("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+		given = auth[7:]
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
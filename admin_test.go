@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
+)
+
+type (
+	popularityMock []weather.LocationCount
+	expirerMock    map[string]time.Duration
+)
+
+func (p popularityMock) Top(n int) []weather.LocationCount {
+	return p[:n]
+}
+
+func (e expirerMock) ExpiresIn(location string) (time.Duration, bool) {
+	d, ok := e[location]
+	return d, ok
+}
+
+func TestLocationsHandler(t *testing.T) {
+	const expectedBody = `[{"location":"berlin","requests":3,"cached":true,"expires_in_seconds":90},` +
+		`{"location":"paris","requests":1,"cached":false}]`
+
+	p := popularityMock{
+		{Location: "berlin", Requests: 3},
+		{Location: "paris", Requests: 1},
+		{Location: "rome", Requests: 1},
+	}
+	e := expirerMock{"berlin": 90 * time.Second}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(locationsHandler(p, e, 2)).ServeHTTP(rr, httpGetRequest("/admin/locations"))
+
+	if err := checkResponse(rr.Code, http.StatusOK,
+		strings.TrimSpace(rr.Body.String()), expectedBody); err != nil {
+		t.Error(err)
+	}
+}
+
+type adminCacheMock struct {
+	expirerMock
+	purged    []string
+	refreshed []string
+}
+
+func (c *adminCacheMock) Entries() []weather.CacheEntry { return nil }
+func (c *adminCacheMock) Purge(s string)                { c.purged = append(c.purged, s) }
+func (c *adminCacheMock) Refresh(s string) (*weather.Conditions, error) {
+	c.refreshed = append(c.refreshed, s)
+	return nil, errors.New("some error")
+}
+
+func TestAdminHandler_BuildTemplate(t *testing.T) {
+	expectedFiles := []string{"admin.tmpl"}
+
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			if err := checkTemplates(layouts, expectedFiles); err != nil {
+				t.Error(err)
+			}
+			return template.New("some template")
+		},
+	}
+
+	adminHandler(rdr, &adminCacheMock{}, weather.NewMonitor(nil, 0), worldweatheronline.New(nil), popularityMock{}, 0)
+
+	if !rdr.buildInvoked {
+		t.Error("BuildTemplate was expected to be called")
+	}
+}
+
+func TestPurgeHandler(t *testing.T) {
+	c := &adminCacheMock{}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(purgeHandler(c)).ServeHTTP(rr, httpPostForm("/admin/purge", "location=Berlin"))
+
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/admin?flash=Purged+Berlin" {
+		t.Errorf("expected redirect to the dashboard but got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	if !reflect.DeepEqual(c.purged, []string{"Berlin"}) {
+		t.Errorf("expected Berlin to be purged but got %v", c.purged)
+	}
+
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(purgeHandler(c)).ServeHTTP(rr, httpGetRequest("/admin/purge?location=Paris"))
+
+	if rr.Code != http.StatusMethodNotAllowed || len(c.purged) != 1 {
+		t.Errorf("expected GET to be rejected but got %d and purged %v", rr.Code, c.purged)
+	}
+}
+
+func TestRefreshHandler_Error(t *testing.T) {
+	c := &adminCacheMock{}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(refreshHandler(c)).ServeHTTP(rr, httpPostForm("/admin/refresh", "location=Berlin"))
+
+	if loc := rr.Header().Get("Location"); loc != "/admin?flash=Failed+to+refresh+Berlin%3A+some+error" {
+		t.Errorf("expected the error to be flashed but got redirect to %s", loc)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	h := requireToken("secret", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	basic := httpGetRequest("/admin")
+	basic.SetBasicAuth("admin", "secret")
+
+	bearer := httpGetRequest("/admin")
+	bearer.Header.Set("Authorization", "Bearer secret")
+
+	wrong := httpGetRequest("/admin")
+	wrong.SetBasicAuth("admin", "guess")
+
+	crossOrigin := httpPostForm("/admin/purge", "location=Berlin")
+	crossOrigin.Host = "widget.example.com"
+	crossOrigin.Header.Set("Origin", "https://evil.example.com")
+	crossOrigin.Header.Set("Authorization", "Bearer secret")
+
+	sameOrigin := httpPostForm("/admin/purge", "location=Berlin")
+	sameOrigin.Host = "widget.example.com"
+	sameOrigin.Header.Set("Origin", "https://widget.example.com")
+	sameOrigin.Header.Set("Authorization", "Bearer secret")
+
+	for name, tc := range map[string]struct {
+		req  *http.Request
+		code int
+	}{
+		"basic auth":   {basic, http.StatusOK},
+		"bearer":       {bearer, http.StatusOK},
+		"wrong token":  {wrong, http.StatusUnauthorized},
+		"no token":     {httpGetRequest("/admin"), http.StatusUnauthorized},
+		"cross origin": {crossOrigin, http.StatusForbidden},
+		"same origin":  {sameOrigin, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, tc.req)
+		if rr.Code != tc.code {
+			t.Errorf("%s: expected status %d but got %d", name, tc.code, rr.Code)
+		}
+	}
+}
+
+func httpPostForm(path, form string) *http.Request {
+	req, err := http.NewRequest("POST", path, strings.NewReader(form))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
@@ -0,0 +1,53 @@
+package assets
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// precompressed lists the content codings of the sibling files served
+// in place of a file, most preferred first
+var precompressed = []struct{ coding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// AcceptsEncoding reports whether the Accept-Encoding header of the
+// request allows the content coding, e.g. "gzip". The coding itself
+// takes precedence over "*".
+func AcceptsEncoding(r *http.Request, coding string) bool {
+	star := false
+	for _, h := range r.Header["Accept-Encoding"] {
+		for _, part := range strings.Split(h, ",") {
+			fields := strings.Split(part, ";")
+			name := strings.ToLower(strings.TrimSpace(fields[0]))
+			q := 1.0
+			for _, param := range fields[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					q, _ = strconv.ParseFloat(param[2:], 64)
+				}
+			}
+			switch name {
+			case coding:
+				return q > 0
+			case "*":
+				star = q > 0
+			}
+		}
+	}
+	return star
+}
+
+// AddVary adds the header field to the Vary header unless it is listed
+func AddVary(h http.Header, field string) {
+	for _, v := range h["Vary"] {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
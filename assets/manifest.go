@@ -0,0 +1,243 @@
+// Package assets fingerprints the static files so that they can be
+// cached forever: every file is served under a path containing a hash
+// of its content, which changes whenever the file does.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const immutableMaxAge = 365 * 24 * time.Hour
+
+// cssURL matches the url() references of a stylesheet
+var cssURL = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)['"]?\s*\)`)
+
+// Manifest maps the files under a directory to fingerprinted URLs and
+// serves them
+type Manifest struct {
+	prefix string
+	urls   map[string]string
+	files  map[string]*asset
+}
+
+type asset struct {
+	path    string
+	modTime time.Time
+	// content replaces the file on disk, e.g. for rewritten stylesheets
+	content []byte
+	// encoded maps content codings to precompressed siblings of the file
+	encoded map[string]string
+}
+
+// Build hashes every file under dir. The fingerprinted files are served
+// under prefix, e.g. "/static/". url() references of stylesheets to other
+// files are rewritten to their fingerprinted URLs, so that a stylesheet
+// changes whenever the images it uses do. Siblings named like a file plus
+// .br or .gz are served in its place to clients accepting the coding.
+func Build(dir, prefix string) (*Manifest, error) {
+	m := &Manifest{
+		prefix: "/" + strings.Trim(prefix, "/") + "/",
+		urls:   map[string]string{},
+		files:  map[string]*asset{},
+	}
+
+	var stylesheets, siblings []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		switch path.Ext(name) {
+		case ".css":
+			stylesheets = append(stylesheets, name)
+			return nil
+		case ".br", ".gz":
+			siblings = append(siblings, name)
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		m.add(name, &asset{path: p, modTime: info.ModTime()}, b)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range stylesheets {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if rewritten := m.rewriteCSS(name, b); !bytes.Equal(rewritten, b) {
+			m.add(name, &asset{path: p, modTime: info.ModTime(), content: rewritten}, rewritten)
+		} else {
+			m.add(name, &asset{path: p, modTime: info.ModTime()}, b)
+		}
+	}
+
+	for _, name := range siblings {
+		m.addSibling(dir, name)
+	}
+	return m, nil
+}
+
+// URL returns the fingerprinted URL of the file, e.g.
+// "styles/widget.css" becomes "/static/styles/widget.5d41402abc4b.css".
+// Unknown files are referenced by their plain path.
+func (m *Manifest) URL(name string) string {
+	if u, ok := m.urls[strings.TrimPrefix(name, "/")]; ok {
+		return u
+	}
+	return "/" + strings.TrimPrefix(name, "/")
+}
+
+// Lookup returns the fingerprinted URL of the file and whether it is
+// known at all
+func (m *Manifest) Lookup(name string) (string, bool) {
+	u, ok := m.urls[strings.TrimPrefix(name, "/")]
+	return u, ok
+}
+
+// Digest hashes the fingerprinted URLs of all files, it changes whenever
+// any of them does. Pages referencing the assets fold it into their ETags.
+func (m *Manifest) Digest() string {
+	names := make([]string, 0, len(m.urls))
+	for name := range m.urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha1.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, m.urls[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Add fingerprints and serves content generated at startup as if it was
+// the file name
+func (m *Manifest) Add(name string, content []byte) {
+	m.add(name, &asset{path: name, modTime: time.Now(), content: content}, content)
+}
+
+// ServeHTTP serves the fingerprinted files under the prefix with
+// immutable caching
+func (m *Manifest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, m.prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	a, ok := m.files[strings.TrimPrefix(r.URL.Path, m.prefix)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(immutableMaxAge.Seconds())))
+
+	if a.content != nil {
+		http.ServeContent(w, r, a.path, a.modTime, bytes.NewReader(a.content))
+		return
+	}
+
+	p := a.path
+	if len(a.encoded) > 0 {
+		AddVary(w.Header(), "Accept-Encoding")
+		for _, pc := range precompressed {
+			if sibling, ok := a.encoded[pc.coding]; ok && AcceptsEncoding(r, pc.coding) {
+				w.Header().Set("Content-Encoding", pc.coding)
+				p = sibling
+				break
+			}
+		}
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	http.ServeContent(w, r, a.path, a.modTime, f)
+}
+
+// add registers the file under its fingerprinted name
+func (m *Manifest) add(name string, a *asset, content []byte) {
+	sum := sha1.Sum(content)
+	ext := path.Ext(name)
+	hashed := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:])[:12] + ext
+
+	m.urls[name] = m.prefix + hashed
+	m.files[hashed] = a
+}
+
+// addSibling registers a precompressed sibling of a file. Stylesheets
+// that were rewritten differ from their siblings, which are ignored.
+func (m *Manifest) addSibling(dir, name string) {
+	ext := path.Ext(name)
+	u, ok := m.urls[strings.TrimSuffix(name, ext)]
+	if !ok {
+		return
+	}
+	a := m.files[strings.TrimPrefix(u, m.prefix)]
+	if a.content != nil {
+		return
+	}
+	for _, pc := range precompressed {
+		if pc.ext == ext {
+			if a.encoded == nil {
+				a.encoded = map[string]string{}
+			}
+			a.encoded[pc.coding] = filepath.Join(dir, filepath.FromSlash(name))
+		}
+	}
+}
+
+// rewriteCSS replaces the url() references of the stylesheet to known
+// files with their fingerprinted URLs
+func (m *Manifest) rewriteCSS(name string, css []byte) []byte {
+	return cssURL.ReplaceAllFunc(css, func(ref []byte) []byte {
+		match := cssURL.FindSubmatch(ref)
+		target := string(match[2])
+		if strings.Contains(target, ":") {
+			return ref
+		}
+
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(path.Dir(name), target)
+		}
+
+		u, ok := m.urls[target]
+		if !ok {
+			return ref
+		}
+		return []byte(fmt.Sprintf("url(%s%s%s)", match[1], u, match[1]))
+	})
+}
@@ -0,0 +1,209 @@
+package assets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func serve(m *Manifest, url string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+	return rr
+}
+
+func TestManifest_URL(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"images/hat.png": "hat"})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := m.URL("images/hat.png")
+	if !regexp.MustCompile(`^/static/images/hat\.[0-9a-f]{12}\.png$`).MatchString(u) {
+		t.Errorf("unexpected fingerprinted URL %s", u)
+	}
+	if m.URL("/images/hat.png") != u {
+		t.Errorf("expected leading slashes to be ignored")
+	}
+	if u := m.URL("images/missing.png"); u != "/images/missing.png" {
+		t.Errorf("expected unknown files to keep their path but got %s", u)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "images", "hat.png"), []byte("new hat"), 0644)
+	m2, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2.URL("images/hat.png") == u {
+		t.Errorf("expected the URL to change with the content")
+	}
+}
+
+func TestManifest_RewritesStylesheets(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"images/hat.png":    "hat",
+		"images/coat.png":   "coat",
+		"styles/widget.css": `.hat{background:url("/images/hat.png")} .coat{background:url(../images/coat.png)} .x{background:url(data:image/png;base64,AA==)} .y{background:url(missing.png)}`,
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := serve(m, m.URL("styles/widget.css"))
+	css := rr.Body.String()
+	for _, expected := range []string{
+		`url("` + m.URL("images/hat.png") + `")`,
+		`url(` + m.URL("images/coat.png") + `)`,
+		`url(data:image/png;base64,AA==)`,
+		`url(missing.png)`,
+	} {
+		if !strings.Contains(css, expected) {
+			t.Errorf("expected stylesheet to contain %s but got %s", expected, css)
+		}
+	}
+}
+
+func TestManifest_ServeHTTP(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"images/hat.png": "hat"})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := serve(m, m.URL("images/hat.png"))
+	if rr.Code != http.StatusOK || rr.Body.String() != "hat" {
+		t.Errorf("expected the file but got %d %s", rr.Code, rr.Body.String())
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Errorf("expected fingerprinted files to be immutable but got %s", cc)
+	}
+
+	for _, url := range []string{"/static/images/hat.png", "/static/images/hat.000000000000.png", "/images/hat.png"} {
+		if rr := serve(m, url); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 but got %d", url, rr.Code)
+		}
+	}
+}
+
+func TestManifest_ServesPrecompressedSiblings(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"images/hat.svg":    "<svg/>",
+		"images/hat.svg.gz": "gzipped",
+		"images/hat.svg.br": "brotli",
+		"images/coat.svg":   "<svg/><!-- coat -->",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := m.URL("images/hat.svg.gz"); u != "/images/hat.svg.gz" {
+		t.Errorf("expected siblings not to be fingerprinted but got %s", u)
+	}
+
+	for acceptEncoding, expected := range map[string]string{
+		"":               "<svg/>",
+		"gzip":           "gzipped",
+		"gzip, br":       "brotli",
+		"br;q=0, gzip":   "gzipped",
+		"identity":       "<svg/>",
+		"*":              "brotli",
+		"*;q=0, gzip":    "gzipped",
+		"gzip;q=0.0, br": "brotli",
+	} {
+		req := httptest.NewRequest("GET", m.URL("images/hat.svg"), nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		m.ServeHTTP(rr, req)
+
+		if rr.Body.String() != expected {
+			t.Errorf("Accept-Encoding %q: expected %s but got %s", acceptEncoding, expected, rr.Body.String())
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "image/svg+xml" {
+			t.Errorf("Accept-Encoding %q: expected the type of the plain file but got %s", acceptEncoding, ct)
+		}
+		if rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: expected Vary: Accept-Encoding", acceptEncoding)
+		}
+	}
+
+	if rr := serve(m, m.URL("images/coat.svg")); rr.Header().Get("Vary") != "" {
+		t.Errorf("expected no Vary header for files without siblings")
+	}
+}
+
+func TestManifest_Add(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"images/hat.png": "hat"})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Lookup("characters/gopher.css"); ok {
+		t.Fatal("expected files not added yet to be unknown")
+	}
+	if u, ok := m.Lookup("/images/hat.png"); !ok || u != m.URL("images/hat.png") {
+		t.Errorf("expected the fingerprinted URL of known files but got %s", u)
+	}
+
+	m.Add("characters/gopher.css", []byte(".hat{}"))
+	u, ok := m.Lookup("characters/gopher.css")
+	if !ok || !regexp.MustCompile(`^/static/characters/gopher\.[0-9a-f]{12}\.css$`).MatchString(u) {
+		t.Fatalf("unexpected URL of the added file %s", u)
+	}
+	if rr := serve(m, u); rr.Code != http.StatusOK || rr.Body.String() != ".hat{}" {
+		t.Errorf("expected the added content to be served but got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestManifest_Digest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.js": "a", "b.css": "b"})
+	defer os.RemoveAll(dir)
+
+	m, err := Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := m.Digest()
+	if again, _ := Build(dir, "/static/"); again.Digest() != digest {
+		t.Error("expected the digest of unchanged files to be stable")
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "a.js"), []byte("changed"), 0644)
+	if changed, _ := Build(dir, "/static/"); changed.Digest() == digest {
+		t.Error("expected the digest to change with a file")
+	}
+	if m.Add("generated.css", []byte("c")); m.Digest() == digest {
+		t.Error("expected the digest to change with an added file")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+// badgeHandler renders a compact SVG badge with the temperature at the
+// requested location, colored by the requested theme
+func badgeHandler(rdr renderer, forecaster forecaster, cache expirer) func(w http.ResponseWriter, r *http.Request) {
+	tmpl := rdr.Template("badge.tmpl")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := forecast(forecaster, r)
+		if err != nil {
+			renderError(w, r, rdr, err)
+			return
+		}
+
+		theme, ok := tpl.BadgeThemes[r.URL.Query().Get("theme")]
+		if !ok {
+			theme = tpl.DefaultBadgeTheme
+		}
+
+		var b bytes.Buffer
+		if err := rdr.RenderTemplate(&b, tmpl, tpl.NewBadge(c.Location, c.Celsius, c.Condition, theme)); err != nil {
+			renderError(w, r, rdr, err)
+			return
+		}
+
+		if notModified(w, r, newETag(b.String()), freshFor(cache, r)) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		b.WriteTo(w)
+	}
+}
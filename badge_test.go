@@ -0,0 +1,63 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestBadgeHandler(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: -3, Description: "Light snow", Condition: weather.Snow}, nil
+		},
+	}
+
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			if err := checkTemplates(layouts, []string{"badge.tmpl"}); err != nil {
+				t.Error(err)
+			}
+			return template.New("badge")
+		},
+		renderFunc: func(w io.Writer, tmpl *template.Template, v interface{}) error {
+			if b, ok := v.(tpl.Badge); !ok || b.Label != "Berlin" || b.Value != "-3°C" || b.Icon != "snow" {
+				t.Errorf("unexpected badge %v", v)
+			}
+			w.Write([]byte("<svg/>"))
+			return nil
+		},
+	}
+
+	h := http.HandlerFunc(badgeHandler(rdr, forecaster, fixedExpiry(time.Minute)))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httpGetRequest("/badge?location=Berlin"))
+
+	if err := checkResponse(rr.Code, http.StatusOK, rr.Body.String(), "<svg/>"); err != nil {
+		t.Error(err)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected content type image/svg+xml but got %s", ct)
+	}
+
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected the badge to have an ETag")
+	}
+
+	req := httpGetRequest("/badge?location=Berlin")
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if err := checkResponse(rr.Code, http.StatusNotModified, rr.Body.String(), ""); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newETag returns a strong ETag hashing the given parts
+func newETag(parts ...interface{}) string {
+	h := sha1.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// notModified sets the ETag and the Cache-Control of a response that may
+// be cached for maxAge, and answers with 304 Not Modified if the client
+// already has the response. Handlers return early when it does. They pass
+// the time the cache holds the conditions, see freshFor, so that clients
+// revalidate once the conditions may have changed.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, maxAge time.Duration) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+
+	if !matchesETag(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// freshFor returns the time the conditions of the requested location
+// stay fresh, or zero when they have expired or aren't cached
+func freshFor(e expirer, r *http.Request) time.Duration {
+	d, ok := e.ExpiresIn(r.URL.Query().Get("location"))
+	if !ok || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// uncached undoes notModified making the response uncacheable
+func uncached(w http.ResponseWriter) {
+	w.Header().Del("ETag")
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// matchesETag reports whether the If-None-Match header lists the etag,
+// comparing weakly as RFC 7232 asks for
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// templateVersion hashes the template files so that ETags change when
+// the templates do. Files that can't be read only contribute their name.
+func templateVersion(files ...string) string {
+	h := sha1.New()
+	for _, f := range files {
+		io.WriteString(h, f)
+		if b, err := ioutil.ReadFile(f); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/wwgberlin/go-weather-widget/assets"
+	"github.com/wwgberlin/go-weather-widget/character"
+	"github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+// characterStylesheets adds the stylesheet of the character pack to the
+// manifest, plus one for every theme with alternate artwork for the pack,
+// and returns the character helper naming the stylesheet of a theme
+func characterStylesheets(m *assets.Manifest, pack *character.Pack, themes tpl.Themes) func(*tpl.Theme) string {
+	packDir := path.Join("characters", pack.ID)
+	packImage := func(image string) string {
+		return m.URL(path.Join(packDir, image))
+	}
+
+	stylesheet := packDir + ".css"
+	m.Add(stylesheet, pack.Stylesheet(packImage))
+
+	themed := map[string]string{}
+	for _, t := range themes {
+		if t.Images == "" {
+			continue
+		}
+		dir, replaced := path.Join(t.Images, pack.ID), false
+		css := pack.Stylesheet(func(image string) string {
+			if u, ok := m.Lookup(path.Join(dir, image)); ok {
+				replaced = true
+				return u
+			}
+			return packImage(image)
+		})
+		if replaced {
+			themed[t.Name] = dir + ".css"
+			m.Add(dir+".css", css)
+		}
+	}
+
+	return func(t *tpl.Theme) string {
+		if t != nil {
+			if name, ok := themed[t.Name]; ok {
+				return name
+			}
+		}
+		return stylesheet
+	}
+}
+
+// legacyImagesHandler redirects the /images/ URLs the gopher artwork was
+// served from before it became a character pack to its fingerprinted
+// URLs, so that existing embeds keep working. The redirects are temporary
+// since the fingerprints change with the images.
+func legacyImagesHandler(m *assets.Manifest) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, "/images/"))
+		u, ok := m.Lookup(path.Join("characters", "gopher", name))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, u, http.StatusFound)
+	})
+}
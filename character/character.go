@@ -0,0 +1,160 @@
+// Package character loads the character packs dressed by the widget. A
+// pack is a directory with the base art, an image per garment and a
+// character.json manifest:
+//
+//	{
+//		"name": "Gopher",
+//		"base": "base_gopher.png",
+//		"garments": {
+//			"boots": {"image": "boots.png", "z": 10},
+//			"scarf": {"image": "scarf.png", "z": 90}
+//		},
+//		"fallbacks": {
+//			"winterhat": "hat",
+//			"sunglasses": ""
+//		}
+//	}
+//
+// Garments are drawn on top of the base in the order of their z. A
+// garment the pack doesn't provide is replaced by its fallback, which may
+// fall back itself, or left out.
+package character
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// ManifestName is the file name of the manifest in a pack directory
+const ManifestName = "character.json"
+
+// garmentName restricts garments to names that make CSS classes
+var garmentName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Pack is a character with its garments
+type Pack struct {
+	// ID is the name of the pack directory
+	ID string
+	// Dir is the pack directory
+	Dir string
+
+	Name      string             `json:"name"`
+	Base      string             `json:"base"`
+	Garments  map[string]Garment `json:"garments"`
+	Fallbacks map[string]string  `json:"fallbacks"`
+}
+
+// Garment is the image of a piece of clothing and its place in the
+// drawing order
+type Garment struct {
+	Image string `json:"image"`
+	Z     int    `json:"z"`
+}
+
+// Load reads the pack in dir and checks that its images exist and its
+// fallbacks lead somewhere
+func Load(dir string) (*Pack, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pack{ID: filepath.Base(dir), Dir: dir}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("invalid character manifest %s: %s", filepath.Join(dir, ManifestName), err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid character pack %s: %s", dir, err)
+	}
+	return p, nil
+}
+
+func (p *Pack) validate() error {
+	if p.Base == "" {
+		return fmt.Errorf("no base image")
+	}
+	images := []string{p.Base}
+	for name, g := range p.Garments {
+		if !garmentName.MatchString(name) {
+			return fmt.Errorf("invalid garment name %q", name)
+		}
+		images = append(images, g.Image)
+	}
+	for _, image := range images {
+		if _, err := os.Stat(filepath.Join(p.Dir, image)); err != nil {
+			return err
+		}
+	}
+
+	for garment, fallback := range p.Fallbacks {
+		if _, ok := p.Garments[garment]; ok {
+			return fmt.Errorf("fallback of %s, which is provided", garment)
+		}
+		if _, ok := p.resolve(garment); !ok && fallback != "" {
+			return fmt.Errorf("fallback of %s leads to no provided garment", garment)
+		}
+	}
+	return nil
+}
+
+// Dress returns the garments of the pack to draw for the clothes, e.g.
+// returned by tpl.Clothes, in drawing order. Clothes the pack doesn't
+// provide are replaced by their fallbacks or left out.
+func (p *Pack) Dress(clothes []string) []string {
+	var garments []string
+	seen := map[string]bool{}
+	for _, c := range clothes {
+		g, ok := p.resolve(c)
+		if !ok || seen[g] {
+			continue
+		}
+		seen[g] = true
+		garments = append(garments, g)
+	}
+
+	sort.SliceStable(garments, func(i, j int) bool {
+		return p.Garments[garments[i]].Z < p.Garments[garments[j]].Z
+	})
+	return garments
+}
+
+// resolve follows the fallbacks of the garment to one the pack provides
+func (p *Pack) resolve(garment string) (string, bool) {
+	for i := 0; i <= len(p.Fallbacks); i++ {
+		if _, ok := p.Garments[garment]; ok {
+			return garment, true
+		}
+		next, ok := p.Fallbacks[garment]
+		if !ok || next == "" {
+			return "", false
+		}
+		garment = next
+	}
+	// the fallbacks go round in circles
+	return "", false
+}
+
+// Stylesheet returns the CSS putting the base art on div.gopher and the
+// garments on its child divs of their class, stacked by their z. url
+// returns the URL of an image of the pack.
+func (p *Pack) Stylesheet(url func(image string) string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "div.gopher {\n\tbackground-image: url(%q);\n}\n", url(p.Base))
+
+	names := make([]string, 0, len(p.Garments))
+	for name := range p.Garments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := p.Garments[name]
+		fmt.Fprintf(&b, "\ndiv.gopher > div.%s {\n\tbackground-image: url(%q);\n\tz-index: %d;\n}\n", name, url(g.Image), g.Z)
+	}
+	return b.Bytes()
+}
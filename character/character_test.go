@@ -0,0 +1,134 @@
+package character
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writePack writes the manifest and an empty file per image
+func writePack(t *testing.T, manifest string, images ...string) string {
+	dir, err := ioutil.TempDir("", "character")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := append(images, ManifestName)
+	for _, name := range files {
+		content := ""
+		if name == ManifestName {
+			content = manifest
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	p, err := Load("../public/static/characters/gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "gopher" || p.Name != "Gopher" || p.Base != "base_gopher.png" {
+		t.Errorf("unexpected pack %+v", p)
+	}
+	if g := p.Garments["umbrella"]; g.Image != "umbrella.png" || g.Z != 99 {
+		t.Errorf("unexpected umbrella %+v", g)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, manifest string
+		images         []string
+		expected       string
+	}{
+		{"broken json", `{"base":`, []string{"base.png"}, "invalid character manifest"},
+		{"no base", `{"garments": {}}`, nil, "no base image"},
+		{"missing image", `{"base": "base.png", "garments": {"hat": {"image": "hat.png"}}}`, []string{"base.png"}, "hat.png"},
+		{"garment name", `{"base": "base.png", "garments": {"Top Hat": {"image": "hat.png"}}}`, []string{"base.png", "hat.png"}, `invalid garment name "Top Hat"`},
+		{"fallback of provided", `{"base": "base.png", "garments": {"hat": {"image": "hat.png"}}, "fallbacks": {"hat": ""}}`, []string{"base.png", "hat.png"}, "fallback of hat, which is provided"},
+		{"dangling fallback", `{"base": "base.png", "fallbacks": {"winterhat": "hat"}}`, []string{"base.png"}, "fallback of winterhat leads to no provided garment"},
+		{"circular fallback", `{"base": "base.png", "fallbacks": {"winterhat": "hat", "hat": "winterhat"}}`, []string{"base.png"}, "leads to no provided garment"},
+	} {
+		dir := writePack(t, tc.manifest, tc.images...)
+		defer os.RemoveAll(dir)
+
+		_, err := Load(dir)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected an error containing %q but got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+func TestPack_Dress(t *testing.T) {
+	p := &Pack{
+		Garments: map[string]Garment{
+			"boots": {Z: 10},
+			"coat":  {Z: 30},
+			"hat":   {Z: 50},
+			"scarf": {Z: 90},
+		},
+		Fallbacks: map[string]string{
+			"winterhat":  "hat",
+			"beanie":     "winterhat",
+			"sunglasses": "",
+		},
+	}
+
+	for _, tc := range []struct {
+		clothes, expected []string
+	}{
+		{[]string{"scarf", "boots", "coat"}, []string{"boots", "coat", "scarf"}},
+		{[]string{"beanie", "sunglasses", "boots"}, []string{"boots", "hat"}},
+		{[]string{"hat", "winterhat", "beanie"}, []string{"hat"}},
+		{[]string{"crown"}, nil},
+	} {
+		if garments := p.Dress(tc.clothes); !reflect.DeepEqual(garments, tc.expected) {
+			t.Errorf("%v: expected %v but got %v", tc.clothes, tc.expected, garments)
+		}
+	}
+}
+
+func TestPack_Dress_CircularFallbacks(t *testing.T) {
+	p := &Pack{
+		Garments:  map[string]Garment{"boots": {}},
+		Fallbacks: map[string]string{"hat": "winterhat", "winterhat": "hat"},
+	}
+	if garments := p.Dress([]string{"hat", "boots"}); !reflect.DeepEqual(garments, []string{"boots"}) {
+		t.Errorf("expected circular fallbacks to be left out but got %v", garments)
+	}
+}
+
+func TestPack_Stylesheet(t *testing.T) {
+	p := &Pack{
+		Base: "base.png",
+		Garments: map[string]Garment{
+			"scarf": {Image: "scarf.png", Z: 90},
+			"boots": {Image: "boots.png", Z: 10},
+		},
+	}
+	css := string(p.Stylesheet(func(image string) string { return "/static/" + image }))
+
+	expected := `div.gopher {
+	background-image: url("/static/base.png");
+}
+
+div.gopher > div.boots {
+	background-image: url("/static/boots.png");
+	z-index: 10;
+}
+
+div.gopher > div.scarf {
+	background-image: url("/static/scarf.png");
+	z-index: 90;
+}
+`
+	if css != expected {
+		t.Errorf("unexpected stylesheet:\n%s", css)
+	}
+}
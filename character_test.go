@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/assets"
+	"github.com/wwgberlin/go-weather-widget/character"
+	"github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+func TestCharacterStylesheets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"characters/fox/character.json":         `{"base": "base.png", "garments": {"hat": {"image": "hat.png", "z": 50}}}`,
+		"characters/fox/base.png":               "fox",
+		"characters/fox/hat.png":                "hat",
+		"themes/dark/images/fox/hat.png":        "dark hat",
+		"themes/contrast/images/gopher/hat.png": "other pack",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		ioutil.WriteFile(p, []byte(content), 0644)
+	}
+
+	m, err := assets.Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack, err := character.Load(filepath.Join(dir, "characters", "fox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	themes := tpl.Themes{
+		"light":    {Name: "light"},
+		"dark":     {Name: "dark", Images: "themes/dark/images"},
+		"contrast": {Name: "contrast", Images: "themes/contrast/images"},
+	}
+	helper := characterStylesheets(m, pack, themes)
+
+	for _, tc := range []struct {
+		theme             *tpl.Theme
+		stylesheet, image string
+	}{
+		{nil, "characters/fox.css", "characters/fox/hat.png"},
+		{themes["light"], "characters/fox.css", "characters/fox/hat.png"},
+		{themes["contrast"], "characters/fox.css", "characters/fox/hat.png"},
+		{themes["dark"], "themes/dark/images/fox.css", "themes/dark/images/fox/hat.png"},
+	} {
+		name := helper(tc.theme)
+		if name != tc.stylesheet {
+			t.Errorf("%v: expected %s but got %s", tc.theme, tc.stylesheet, name)
+			continue
+		}
+		u, ok := m.Lookup(name)
+		if !ok {
+			t.Errorf("expected %s to be served", name)
+			continue
+		}
+		rr := httptest.NewRecorder()
+		m.ServeHTTP(rr, httpGetRequest(u))
+		css := rr.Body.String()
+		if !strings.Contains(css, m.URL(tc.image)) || !strings.Contains(css, m.URL("characters/fox/base.png")) {
+			t.Errorf("%s: expected the fingerprinted images in\n%s", name, css)
+		}
+	}
+}
+
+func TestLegacyImagesHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "characters", "gopher"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "characters", "gopher", "hat.png"), []byte("hat"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644)
+
+	m, err := assets.Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := legacyImagesHandler(m)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httpGetRequest("/images/hat.png"))
+	if loc := rr.Header().Get("Location"); rr.Code != http.StatusFound || loc != m.URL("characters/gopher/hat.png") {
+		t.Errorf("expected a redirect to the fingerprinted image but got %d %s", rr.Code, loc)
+	}
+
+	for _, p := range []string{"/images/missing.png", "/images/../../secret.txt"} {
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httpGetRequest(p))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 but got %d", p, rr.Code)
+		}
+	}
+}
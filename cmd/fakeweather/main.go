@@ -0,0 +1,156 @@
+// Command fakeweather is a stand-in for the World Weather Online API
+// serving scripted scenarios, so that the widget can be developed
+// without an API key:
+//
+//	go build ./cmd/fakeweather && ./fakeweather -config=./cmd/fakeweather/scenarios.json
+//	go build . && ./go-weather-widget -api_key=fake -api_url=http://localhost:8081
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	response struct {
+		Data data `json:"data"`
+	}
+
+	data struct {
+		Error      []message          `json:"error,omitempty"`
+		Request    []requestInfo      `json:"request,omitempty"`
+		Conditions []currentCondition `json:"current_condition,omitempty"`
+		TimeZone   []timeZone         `json:"time_zone,omitempty"`
+		Weather    []day              `json:"weather,omitempty"`
+		Alerts     *alerts            `json:"alerts,omitempty"`
+	}
+
+	message struct {
+		Msg string `json:"msg"`
+	}
+
+	requestInfo struct {
+		Type  string `json:"type"`
+		Query string `json:"query"`
+	}
+
+	currentCondition struct {
+		ObservationTime string  `json:"observation_time"`
+		TempC           string  `json:"temp_C"`
+		TempF           string  `json:"temp_F"`
+		WeatherCode     string  `json:"weatherCode"`
+		WeatherDesc     []value `json:"weatherDesc"`
+		WindspeedKmph   string  `json:"windspeedKmph"`
+	}
+
+	value struct {
+		Value string `json:"value"`
+	}
+
+	timeZone struct {
+		LocalTime string `json:"localtime"`
+		UTCOffset string `json:"utcOffset"`
+	}
+
+	day struct {
+		Date      string      `json:"date"`
+		Astronomy []astronomy `json:"astronomy"`
+	}
+
+	astronomy struct {
+		Sunrise string `json:"sunrise"`
+		Sunset  string `json:"sunset"`
+	}
+
+	alerts struct {
+		Alert []alert `json:"alert"`
+	}
+
+	alert struct {
+		Headline  string `json:"headline"`
+		Severity  string `json:"severity"`
+		Event     string `json:"event"`
+		Effective string `json:"effective,omitempty"`
+		Expires   string `json:"expires,omitempty"`
+	}
+)
+
+// The sun rises and sets at the same time everywhere
+const (
+	sunrise = "06:00 AM"
+	sunset  = "08:00 PM"
+)
+
+func weatherHandler(c *config) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		s := c.scenario(q.Get("q"))
+
+		time.Sleep(s.latency)
+
+		if s.Status != 0 && s.Status != http.StatusOK {
+			http.Error(w, http.StatusText(s.Status), s.Status)
+			return
+		}
+
+		var res response
+		switch {
+		case q.Get("key") == "":
+			res.Data.Error = []message{{Msg: "API key is invalid"}}
+		case q.Get("q") == "":
+			res.Data.Error = []message{{Msg: "There is no weather data available for the date provided."}}
+		case s.Error != "":
+			res.Data.Error = []message{{Msg: s.Error}}
+		default:
+			res.Data = conditions(q.Get("q"), s)
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+func conditions(location string, s *scenario) data {
+	today := time.Now().UTC().Format("2006-01-02")
+	d := data{
+		Request: []requestInfo{{Type: "City", Query: strings.TrimSpace(location)}},
+		Conditions: []currentCondition{{
+			ObservationTime: time.Now().UTC().Format("03:04 PM"),
+			TempC:           strconv.Itoa(s.Celsius),
+			TempF:           strconv.Itoa(s.Celsius*9/5 + 32),
+			WeatherCode:     strconv.Itoa(s.WeatherCode),
+			WeatherDesc:     []value{{Value: s.Description}},
+			WindspeedKmph:   strconv.Itoa(s.WindKmph),
+		}},
+		TimeZone: []timeZone{{LocalTime: today + " " + s.localTime, UTCOffset: "0.0"}},
+		Weather:  []day{{Date: today, Astronomy: []astronomy{{Sunrise: sunrise, Sunset: sunset}}}},
+	}
+	if len(s.Alerts) > 0 {
+		d.Alerts = &alerts{Alert: s.Alerts}
+	}
+	return d
+}
+
+func main() {
+	port := flag.String("port", "8081", "Optional: port to serve on")
+	configPath := flag.String("config", "./cmd/fakeweather/scenarios.json", "Optional: scenario config file")
+	flag.Parse()
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	http.HandleFunc("/premium/v1/weather.ashx", weatherHandler(c))
+
+	log.Printf("Fake weather serving on http://localhost:%s ...", *port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", *port), nil))
+}
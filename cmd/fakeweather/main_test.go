@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
+)
+
+func TestWeatherHandler(t *testing.T) {
+	c, err := loadConfig("./scenarios.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(weatherHandler(c)))
+	defer srv.Close()
+
+	// every case gets a client of its own, BadKey benches the key
+	newClient := func() *worldweatheronline.Client {
+		return worldweatheronline.New([]string{"fake"}, worldweatheronline.WithAPIURL(srv.URL))
+	}
+
+	for location, expected := range map[string]weather.Conditions{
+		"Berlin":    {Location: "City Berlin", Celsius: 9, Description: "Light rain", Condition: weather.Rain, IsDay: true},
+		"Oslo":      {Location: "City Oslo", Celsius: -4, Description: "Heavy snow", Condition: weather.Snow, IsDay: true},
+		"Somewhere": {Location: "City Somewhere", Celsius: 17, Description: "Partly cloudy", Condition: weather.PartlyCloudy, IsDay: true},
+		"Reykjavik": {Location: "City Reykjavik", Celsius: 24, Description: "Clear", Condition: weather.Clear},
+		"Hamburg": {
+			Location: "City Hamburg", Celsius: 6, Description: "Heavy rain", Condition: weather.Rain, IsDay: true, WindKmph: 95,
+			Alerts: []weather.Alert{{Type: weather.WindAlert, Severity: weather.Severe, Text: "Severe storm warning for the coast"}},
+		},
+	} {
+		conditions, err := newClient().Forecast(location)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", location, err)
+		} else if !reflect.DeepEqual(*conditions, expected) {
+			t.Errorf("%s: expected %v but got %v", location, expected, *conditions)
+		}
+	}
+
+	for location, expected := range map[string]string{
+		"Nowhere": "no location matching",
+		"Broken":  "status 503",
+		"BadKey":  "API key is invalid",
+	} {
+		if _, err := newClient().Forecast(location); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q but got %v", location, expected, err)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for name, c := range map[string]*config{
+		"undefined default": {
+			Default:   "sunny",
+			Scenarios: map[string]*scenario{},
+		},
+		"undefined location scenario": {
+			Default:   "sunny",
+			Locations: map[string]string{"berlin": "rain"},
+			Scenarios: map[string]*scenario{"sunny": {}},
+		},
+		"invalid latency": {
+			Default:   "sunny",
+			Scenarios: map[string]*scenario{"sunny": {Latency: "soon"}},
+		},
+		"invalid local time": {
+			Default:   "sunny",
+			Scenarios: map[string]*scenario{"sunny": {LocalTime: "midnight"}},
+		},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("%s: expected config to be invalid", name)
+		}
+	}
+}
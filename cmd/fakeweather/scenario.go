@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// config maps locations to named scenarios
+type config struct {
+	// Default is the scenario of locations not listed in Locations
+	Default   string               `json:"default"`
+	Locations map[string]string    `json:"locations"`
+	Scenarios map[string]*scenario `json:"scenarios"`
+}
+
+// scenario describes how the server answers for a location
+type scenario struct {
+	Celsius     int    `json:"celsius"`
+	Description string `json:"description"`
+	WeatherCode int    `json:"weather_code"`
+	WindKmph    int    `json:"wind_kmph"`
+	// Alerts are answered as the API words them
+	Alerts []alert `json:"alerts"`
+
+	// Error is answered as an API error message, e.g. "API key is invalid"
+	Error string `json:"error"`
+	// Status is answered instead of 200 OK when set
+	Status int `json:"status"`
+	// Latency delays the answer, e.g. "2s"
+	Latency string `json:"latency"`
+	// LocalTime is the time of day at the location, noon if not set, e.g.
+	// "23:30" for the night
+	LocalTime string `json:"local_time"`
+
+	latency   time.Duration
+	localTime string
+}
+
+func loadConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", path, err)
+	}
+	return &c, c.validate()
+}
+
+func (c *config) validate() error {
+	for name, s := range c.Scenarios {
+		s.localTime = "12:00"
+		if s.LocalTime != "" {
+			if _, err := time.Parse("15:04", s.LocalTime); err != nil {
+				return fmt.Errorf("scenario %s: invalid local time: %s", name, err)
+			}
+			s.localTime = s.LocalTime
+		}
+
+		if s.Latency == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s.Latency)
+		if err != nil {
+			return fmt.Errorf("scenario %s: invalid latency: %s", name, err)
+		}
+		s.latency = d
+	}
+
+	if _, ok := c.Scenarios[c.Default]; !ok {
+		return fmt.Errorf("default scenario %q is not defined", c.Default)
+	}
+
+	locations := make(map[string]string, len(c.Locations))
+	for l, name := range c.Locations {
+		if _, ok := c.Scenarios[name]; !ok {
+			return fmt.Errorf("scenario %q of location %s is not defined", name, l)
+		}
+		locations[normalize(l)] = name
+	}
+	c.Locations = locations
+	return nil
+}
+
+// scenario returns the scenario for the location
+func (c *config) scenario(location string) *scenario {
+	if name, ok := c.Locations[normalize(location)]; ok {
+		return c.Scenarios[name]
+	}
+	return c.Scenarios[c.Default]
+}
+
+func normalize(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
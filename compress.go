@@ -0,0 +1,152 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/wwgberlin/go-weather-widget/assets"
+)
+
+// gzipSuffix marks the ETags of gzipped responses, which differ from the
+// ETags of the plain ones
+const gzipSuffix = "-gzip"
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// compressible lists the content types worth compressing. Images other
+// than SVG are compressed already.
+var compressible = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// compress gzips the responses of h for clients accepting it, once they
+// reach minSize bytes. Responses that are encoded already, such as the
+// precompressed assets, are left alone. There is no Brotli on the fly,
+// see the README.
+func compress(h http.Handler, minSize int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assets.AddVary(w.Header(), "Accept-Encoding")
+		if !assets.AcceptsEncoding(r, "gzip") || r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// revalidations of gzipped responses carry their ETag, the 304
+		// has to carry it again
+		inm := r.Header.Get("If-None-Match")
+		gzipped := strings.Contains(inm, gzipSuffix+`"`)
+		if gzipped {
+			r.Header.Set("If-None-Match", strings.Replace(inm, gzipSuffix+`"`, `"`, -1))
+		}
+
+		cw := &compressWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK, gzipped: gzipped}
+		defer cw.close()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter holds back the first minSize bytes of a response to
+// decide whether it is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	minSize int
+	// gzipped is set for revalidations of gzipped responses
+	gzipped bool
+
+	status  int
+	buf     []byte
+	started bool
+	gz      *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.started {
+		return
+	}
+	cw.status = code
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.start(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.started {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) >= cw.minSize {
+			cw.start(true)
+		}
+		return len(p), nil
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// start writes the header, compressed if the response is big enough and
+// of a compressible type, followed by the bytes held back
+func (cw *compressWriter) start(big bool) {
+	cw.started = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if big && h.Get("Content-Encoding") == "" && isCompressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		gzipETag(h)
+		cw.gz = gzipWriters.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	} else if cw.status == http.StatusNotModified && cw.gzipped {
+		gzipETag(h)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return
+	}
+	if cw.gz != nil {
+		cw.gz.Write(cw.buf)
+	} else {
+		cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+}
+
+// close flushes a response that stayed below minSize or finishes the
+// gzip stream
+func (cw *compressWriter) close() {
+	if !cw.started {
+		cw.start(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+		gzipWriters.Put(cw.gz)
+	}
+}
+
+// gzipETag marks the ETag as that of the gzipped response
+func gzipETag(h http.Header) {
+	if etag := h.Get("ETag"); strings.HasSuffix(etag, `"`) {
+		h.Set("ETag", strings.TrimSuffix(etag, `"`)+gzipSuffix+`"`)
+	}
+}
+
+func isCompressible(contentType string) bool {
+	for _, prefix := range compressible {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
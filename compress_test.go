@@ -0,0 +1,120 @@
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompress(t *testing.T) {
+	big := strings.Repeat("<p>sunny</p>", 100)
+	h := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			w.Header().Set("ETag", `"abc"`)
+			if r.Header.Get("If-None-Match") == `"abc"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte(big[:600]))
+			w.Write([]byte(big[600:]))
+		case "/small":
+			w.Write([]byte("<p>sunny</p>"))
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(big))
+		case "/encoded":
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte(big))
+		}
+	}), 1024)
+
+	get := func(path, acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httpGetRequest(path)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/big", "gzip, deflate", "")
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected big responses to be gzipped")
+	}
+	if rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding but got %q", rr.Header().Get("Vary"))
+	}
+	if rr.Header().Get("ETag") != `"abc-gzip"` {
+		t.Errorf("expected the ETag of gzipped responses to differ but got %s", rr.Header().Get("ETag"))
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(zr); string(b) != big {
+		t.Errorf("unexpected body after decompression: %s", b)
+	}
+
+	if rr := get("/big", "gzip", `"abc-gzip"`); rr.Code != http.StatusNotModified || rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected gzipped responses to revalidate but got %d", rr.Code)
+	}
+
+	for _, tc := range []struct{ path, acceptEncoding string }{
+		{"/big", ""},
+		{"/big", "gzip;q=0"},
+		{"/small", "gzip"},
+		{"/png", "gzip"},
+	} {
+		rr := get(tc.path, tc.acceptEncoding, "")
+		if rr.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s (%s): expected no compression", tc.path, tc.acceptEncoding)
+		}
+		if rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+			t.Errorf("%s (%s): expected the plain body but got %d", tc.path, tc.acceptEncoding, rr.Code)
+		}
+		if rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s (%s): expected Vary: Accept-Encoding", tc.path, tc.acceptEncoding)
+		}
+	}
+
+	if rr := get("/encoded", "gzip, br", ""); rr.Header().Get("Content-Encoding") != "br" || rr.Body.String() != big {
+		t.Errorf("expected encoded responses to be left alone")
+	}
+}
+
+func TestCompress_Revalidation(t *testing.T) {
+	h := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if notModified(w, r, `"abc"`, time.Minute) {
+			return
+		}
+		w.Write([]byte(strings.Repeat("<p>sunny</p>", 100)))
+	}), 1024)
+
+	for _, acceptEncoding := range []string{"gzip", ""} {
+		req := httpGetRequest("/weather")
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		etag := rr.Header().Get("ETag")
+
+		req = httpGetRequest("/weather")
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotModified || rr.Header().Get("ETag") != etag {
+			t.Errorf("%q: expected a 304 with the ETag %s of the 200 but got %d with %s",
+				acceptEncoding, etag, rr.Code, rr.Header().Get("ETag"))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
+)
+
+// requestIDHeader carries the ID of a request, which error pages show so
+// that users can quote it and operators find it in the log
+const requestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var errMissingLocation = errors.New("missing location")
+
+type errorRenderer interface {
+	RenderError(http.ResponseWriter, int, map[string]interface{})
+}
+
+// requestIDs gives every request an ID, that of the proxy in front of us
+// if it sent a sensible one, and answers with it
+func requestIDs(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// errorStatus maps the error to the status of its error page and a
+// message for users, which unlike the error doesn't leak internals
+func errorStatus(err error) (int, string) {
+	switch e := err.(type) {
+	case *weather.NotFoundError:
+		return http.StatusNotFound, fmt.Sprintf("We couldn't find %s. Check the spelling or try a place nearby.", e.Location)
+	case *weather.UnavailableError:
+		return http.StatusServiceUnavailable, "The weather service is busy. Try again in a little while."
+	}
+	switch err {
+	case errMissingLocation:
+		return http.StatusBadRequest, "Tell us the location to show the weather of."
+	case worldweatheronline.ErrQuotaExceeded, worldweatheronline.ErrNoKeyAvailable:
+		return http.StatusServiceUnavailable, "The weather service is busy. Try again in a little while."
+	}
+	return http.StatusInternalServerError, "Something went wrong on our side. Try again in a little while."
+}
+
+// renderError answers with the error page of the error, rendered by rdr,
+// or as JSON for clients asking for it. The error itself is only logged
+// along with the request ID.
+func renderError(w http.ResponseWriter, r *http.Request, rdr errorRenderer, err error) {
+	status, message := errorStatus(err)
+	id := w.Header().Get(requestIDHeader)
+	log.Printf("request %s %s %s failed with %d: %s", id, r.Method, r.URL.Path, status, err)
+
+	if !wantsJSON(r) {
+		rdr.RenderError(w, status, map[string]interface{}{
+			"message":   message,
+			"requestID": id,
+			"location":  r.URL.Query().Get("location"),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Status    int    `json:"status"`
+		Error     string `json:"error"`
+		RequestID string `json:"requestId,omitempty"`
+	}{status, message, id})
+}
+
+// wantsJSON reports whether the client asked for JSON with format=json
+// or its Accept header rather than for HTML
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
+)
+
+type errorRendererMock struct {
+	status int
+	data   map[string]interface{}
+}
+
+func (m *errorRendererMock) RenderError(w http.ResponseWriter, status int, data map[string]interface{}) {
+	m.status, m.data = status, data
+	w.WriteHeader(status)
+}
+
+func TestErrorStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{&weather.NotFoundError{Location: "Nowhere"}, http.StatusNotFound, "We couldn't find Nowhere."},
+		{errMissingLocation, http.StatusBadRequest, "Tell us the location"},
+		{worldweatheronline.ErrQuotaExceeded, http.StatusServiceUnavailable, "busy"},
+		{worldweatheronline.ErrNoKeyAvailable, http.StatusServiceUnavailable, "busy"},
+		{&weather.UnavailableError{Err: errors.New("request errored with status 503")}, http.StatusServiceUnavailable, "busy"},
+		{errors.New("template: widget failed"), http.StatusInternalServerError, "Something went wrong"},
+	} {
+		status, message := errorStatus(tc.err)
+		if status != tc.status || !strings.Contains(message, tc.message) {
+			t.Errorf("%v: expected %d %q but got %d %q", tc.err, tc.status, tc.message, status, message)
+		}
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	rdr := &errorRendererMock{}
+	rr := httptest.NewRecorder()
+	rr.Header().Set(requestIDHeader, "3f2a9c")
+
+	renderError(rr, httpGetRequest("/weather?location=Nowhere"), rdr, &weather.NotFoundError{Location: "Nowhere"})
+
+	if rdr.status != http.StatusNotFound || rr.Code != http.StatusNotFound {
+		t.Errorf("expected a 404 error page but got %d", rdr.status)
+	}
+	if rdr.data["requestID"] != "3f2a9c" || rdr.data["location"] != "Nowhere" {
+		t.Errorf("expected the request ID and location in the page data but got %v", rdr.data)
+	}
+	if message, _ := rdr.data["message"].(string); !strings.Contains(message, "couldn't find Nowhere") {
+		t.Errorf("unexpected message %q", message)
+	}
+}
+
+func TestRenderError_JSON(t *testing.T) {
+	for _, req := range []*http.Request{
+		httpGetRequest("/weather?location=Berlin&format=json"),
+		func() *http.Request {
+			r := httpGetRequest("/weather?location=Berlin")
+			r.Header.Set("Accept", "application/json")
+			return r
+		}(),
+	} {
+		rdr := &errorRendererMock{}
+		rr := httptest.NewRecorder()
+		rr.Header().Set(requestIDHeader, "3f2a9c")
+
+		renderError(rr, req, rdr, errors.New("request errored with status 503"))
+
+		if rdr.status != 0 {
+			t.Errorf("%s: expected no HTML error page", req.URL)
+		}
+		if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a JSON 500 but got %d %s", req.URL, rr.Code, rr.Header().Get("Content-Type"))
+		}
+		var body struct {
+			Status    int
+			Error     string
+			RequestID string
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Status != http.StatusInternalServerError || body.RequestID != "3f2a9c" || strings.Contains(body.Error, "503") {
+			t.Errorf("%s: unexpected error %+v", req.URL, body)
+		}
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	var seen string
+	h := requestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = w.Header().Get(requestIDHeader)
+	}))
+
+	for _, tc := range []struct {
+		incoming string
+		keep     bool
+	}{
+		{"", false},
+		{"proxy-id.42", true},
+		{"<script>", false},
+		{strings.Repeat("a", 65), false},
+	} {
+		req := httpGetRequest("/")
+		req.Header.Set(requestIDHeader, tc.incoming)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		id := rr.Header().Get(requestIDHeader)
+		if id == "" || id != seen {
+			t.Errorf("%q: expected the request ID to be set before the handler runs but got %q", tc.incoming, id)
+		}
+		if (id == tc.incoming) != tc.keep {
+			t.Errorf("%q: unexpected request ID %q", tc.incoming, id)
+		}
+	}
+}
+
+func TestWidgetHandler_MissingLocation(t *testing.T) {
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			return template.New("some template")
+		},
+	}
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			t.Error("expected no forecast without a location")
+			return nil, nil
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(time.Minute))).ServeHTTP(rr, httpGetRequest("/weather?location=+"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a 400 but got %d", rr.Code)
+	}
+}
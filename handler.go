@@ -4,15 +4,22 @@ import (
 	"html/template"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strings"
 
 	"github.com/wwgberlin/go-weather-widget/weather"
 )
 
 type (
 	renderer interface {
-		BuildTemplate(...string) *template.Template
+		Template(page string) *template.Template
 		RenderTemplate(io.Writer, *template.Template, interface{}) error
+		errorRenderer
+	}
+
+	themedRenderer interface {
+		renderer
+		ThemeTemplates(page string) map[string]*template.Template
+		Files(page string) []string
 	}
 
 	forecaster interface {
@@ -20,10 +27,8 @@ type (
 	}
 )
 
-func indexHandler(layoutsPath string, rdr renderer) func(w http.ResponseWriter, r *http.Request) {
-	files := pathToTemplateFiles(layoutsPath, "index.tmpl", "layouts/layout.tmpl", "layouts/head.tmpl")
-
-	tmpl := rdr.BuildTemplate(files...)
+func indexHandler(rdr renderer) func(w http.ResponseWriter, r *http.Request) {
+	tmpl := rdr.Template("index.tmpl")
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		queryStr := r.URL.Query().Get("location")
@@ -31,40 +36,56 @@ func indexHandler(layoutsPath string, rdr renderer) func(w http.ResponseWriter,
 		if err := rdr.RenderTemplate(w, tmpl, map[string]interface{}{
 			"location": queryStr,
 		}); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			renderError(w, r, rdr, err)
 		}
 	}
 }
 
-// WidgetHandler receives a path to the template files,
-// a renderer and a forecaster and returns an http handler function.
-//
-// Similarly to indexHandler above, instantiate your template with the
-// template files in the enclosing function (but replace index.tmpl with
-// widget.tmpl).
-//
-// The forecaster provides a function Forecast that receives a location (string)
-// and returns weather.Conditions object with the fields:
-// Description, Location, Celsius
-// Call forecaster.Forecast with your request param -
-// r.URL.Query().Get("location")
-//
-// Instantiate a map[string]interface{} to pass to template execution
-// and add the data from the Conditions to the map (use lower case)
-// e.g. m["location"] = c.Location, etc.
-
-func widgetHandler(layoutsPath string, rdr renderer, forecaster forecaster) func(w http.ResponseWriter, r *http.Request) {
+// widgetHandler receives a renderer and a forecaster and returns an http
+// handler function rendering the conditions for the requested location in
+// the theme given by the theme parameter, or the default theme.
+// assetVersion, e.g. the digest of the asset manifest, changes the ETag
+// whenever the assets referenced by the page do.
+func widgetHandler(rdr themedRenderer, forecaster forecaster, assetVersion string, cache expirer) func(w http.ResponseWriter, r *http.Request) {
+	tmpls := rdr.ThemeTemplates("widget.tmpl")
+	version := templateVersion(rdr.Files("widget.tmpl")...)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, "not implemented", http.StatusNotImplemented)
+		theme := r.URL.Query().Get("theme")
+		tmpl, ok := tmpls[theme]
+		if !ok {
+			theme, tmpl = "", tmpls[""]
+		}
+
+		c, err := forecast(forecaster, r)
+		if err != nil {
+			renderError(w, r, rdr, err)
+			return
+		}
+
+		if notModified(w, r, newETag(version, assetVersion, theme, *c), freshFor(cache, r)) {
+			return
+		}
+
+		if err := rdr.RenderTemplate(w, tmpl, map[string]interface{}{
+			"location":    c.Location,
+			"celsius":     c.Celsius,
+			"description": c.Description,
+			"condition":   c.Condition,
+			"isDay":       c.IsDay,
+			"alerts":      c.Alerts,
+		}); err != nil {
+			uncached(w)
+			renderError(w, r, rdr, err)
+		}
 	}
 }
 
-func pathToTemplateFiles(path string, templates ...string) []string {
-	files := make([]string, len(templates))
-
-	for i, t := range templates {
-		files[i] = filepath.Join(path, t)
+// forecast returns the conditions at the location of the request
+func forecast(forecaster forecaster, r *http.Request) (*weather.Conditions, error) {
+	location := r.URL.Query().Get("location")
+	if strings.TrimSpace(location) == "" {
+		return nil, errMissingLocation
 	}
-	return files
+	return forecaster.Forecast(location)
 }
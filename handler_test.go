@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/wwgberlin/go-weather-widget/assets"
 	"github.com/wwgberlin/go-weather-widget/weather"
 )
 
@@ -20,6 +25,7 @@ type (
 		renderInvoked bool
 		buildFunc     func(...string) *template.Template
 		renderFunc    func(io.Writer, *template.Template, interface{}) error
+		themes        []string
 	}
 	forecasterMock struct {
 		invoked  bool
@@ -27,13 +33,36 @@ type (
 	}
 )
 
+// fixedExpiry keeps the conditions of every location fresh for itself
+type fixedExpiry time.Duration
+
+func (d fixedExpiry) ExpiresIn(string) (time.Duration, bool) {
+	return time.Duration(d), true
+}
+
 func (f forecasterMock) Forecast(s string) (*weather.Conditions, error) {
 	return f.forecast(s)
 }
 
-func (rdr *rendererMock) BuildTemplate(dep ...string) *template.Template {
+func (rdr *rendererMock) Template(page string) *template.Template {
 	rdr.buildInvoked = true
-	return rdr.buildFunc(dep...)
+	return rdr.buildFunc(page)
+}
+
+func (rdr *rendererMock) ThemeTemplates(page string) map[string]*template.Template {
+	tmpls := map[string]*template.Template{"": rdr.Template(page)}
+	for _, theme := range rdr.themes {
+		tmpls[theme] = template.New(theme)
+	}
+	return tmpls
+}
+
+func (rdr *rendererMock) Files(page string) []string {
+	return []string{page}
+}
+
+func (rdr *rendererMock) RenderError(w http.ResponseWriter, code int, data map[string]interface{}) {
+	http.Error(w, fmt.Sprint(data["message"]), code)
 }
 
 func (rdr *rendererMock) RenderTemplate(w io.Writer, tmpl *template.Template, data interface{}) error {
@@ -42,11 +71,7 @@ func (rdr *rendererMock) RenderTemplate(w io.Writer, tmpl *template.Template, da
 }
 
 func TestIndexHandler_BuildTemplate(t *testing.T) {
-	expectedFiles := []string{
-		"my/path/index.tmpl",
-		"my/path/layouts/layout.tmpl",
-		"my/path/layouts/head.tmpl",
-	}
+	expectedFiles := []string{"index.tmpl"}
 
 	rdr := &rendererMock{
 		buildFunc: func(layouts ...string) *template.Template {
@@ -57,7 +82,7 @@ func TestIndexHandler_BuildTemplate(t *testing.T) {
 		},
 	}
 
-	indexHandler("my/path/", rdr)
+	indexHandler(rdr)
 
 	if !rdr.buildInvoked {
 		t.Error("BuildTemplate was expected to be called")
@@ -86,7 +111,7 @@ func TestIndexHandler_TestRender(t *testing.T) {
 		},
 	}
 
-	http.HandlerFunc(indexHandler("", rdr)).ServeHTTP(rr, req)
+	http.HandlerFunc(indexHandler(rdr)).ServeHTTP(rr, req)
 
 	if err := checkResponse(rr.Code, http.StatusOK,
 		rr.Body.String(), expectedResult); err != nil {
@@ -108,25 +133,22 @@ func TestIndexHandler_FailToRenderTemplate(t *testing.T) {
 		},
 	}
 
-	http.HandlerFunc(indexHandler("", rdr)).ServeHTTP(rr, req)
+	http.HandlerFunc(indexHandler(rdr)).ServeHTTP(rr, req)
 	body := rr.Body.String()[0 : len(rr.Body.String())-1]
 
+	_, message := errorStatus(errors.New(errMsg))
 	if err := checkResponse(
 		rr.Code,
 		http.StatusInternalServerError,
 		body,
-		errMsg,
+		message,
 	); err != nil {
 		t.Error(strings.Title(err.Error()))
 	}
 }
 
 func TestWidgetHandler_TestBuild(t *testing.T) {
-	expectedFiles := []string{
-		"my/path/widget.tmpl",
-		"my/path/layouts/layout.tmpl",
-		"my/path/layouts/head.tmpl",
-	}
+	expectedFiles := []string{"widget.tmpl"}
 
 	rdr := &rendererMock{
 		buildFunc: func(layouts ...string) *template.Template {
@@ -137,7 +159,7 @@ func TestWidgetHandler_TestBuild(t *testing.T) {
 		},
 	}
 
-	widgetHandler("my/path/", rdr, forecasterMock{})
+	widgetHandler(rdr, forecasterMock{}, "", fixedExpiry(time.Minute))
 
 	if !rdr.buildInvoked {
 		t.Error("BuildTemplate was expected to be called")
@@ -189,7 +211,7 @@ func TestWidgetHandler_TestRender(t *testing.T) {
 		},
 	}
 
-	http.HandlerFunc(widgetHandler("", rdr, forecaster)).ServeHTTP(rr, req)
+	http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(time.Minute))).ServeHTTP(rr, req)
 
 	if err := checkResponse(rr.Code, http.StatusOK,
 		rr.Body.String(), expectedResult); err != nil {
@@ -219,11 +241,12 @@ func TestWidgetHandler_FailToForecast(t *testing.T) {
 		},
 	}
 
-	http.HandlerFunc(widgetHandler("", rdr, forecaster)).ServeHTTP(rr, req)
+	http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(time.Minute))).ServeHTTP(rr, req)
 
+	_, message := errorStatus(errors.New(expectedError))
 	body := strings.TrimSpace(rr.Body.String())
 	if err := checkResponse(rr.Code, http.StatusInternalServerError,
-		body, expectedError); err != nil {
+		body, message); err != nil {
 		t.Error(err.Error())
 	}
 }
@@ -252,10 +275,11 @@ func TestWidgetHandler_FailToRenderTemplate(t *testing.T) {
 		},
 	}
 
-	http.HandlerFunc(widgetHandler("", rdr, forecaster)).ServeHTTP(rr, req)
+	http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(time.Minute))).ServeHTTP(rr, req)
+	_, message := errorStatus(errors.New(expectedError))
 	body := strings.TrimSpace(rr.Body.String())
 	if err := checkResponse(rr.Code, http.StatusInternalServerError,
-		body, expectedError); err != nil {
+		body, message); err != nil {
 		t.Error(err.Error())
 	}
 }
@@ -271,7 +295,7 @@ func httpGetRequest(path string) *http.Request {
 func checkTemplates(layouts, expectedLayouts []string) error {
 	if !reflect.DeepEqual(expectedLayouts, layouts) {
 		return fmt.Errorf(`
-				Unexpected arguments in call to BuildTemplate. 
+				Unexpected arguments in call to Template. 
 				wanted: '%v' received '%v'`,
 			expectedLayouts,
 			layouts,
@@ -298,6 +322,9 @@ func checkMapFields(conditions weather.Conditions, m map[string]interface{}) err
 		"location":    conditions.Location,
 		"celsius":     conditions.Celsius,
 		"description": conditions.Description,
+		"condition":   conditions.Condition,
+		"isDay":       conditions.IsDay,
+		"alerts":      conditions.Alerts,
 	}
 
 	if !reflect.DeepEqual(expected, m) {
@@ -305,3 +332,157 @@ func checkMapFields(conditions weather.Conditions, m map[string]interface{}) err
 	}
 	return nil
 }
+
+func TestWidgetHandler_NotModified(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 5}, nil
+		},
+	}
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			return template.New("some template")
+		},
+		renderFunc: func(w io.Writer, tmpl *template.Template, i interface{}) error {
+			w.Write([]byte("all good"))
+			return nil
+		},
+	}
+
+	h := http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(10*time.Minute)))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httpGetRequest("?location=Berlin"))
+
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Cache-Control") != "public, max-age=600" {
+		t.Fatalf("expected the widget to be cacheable but got headers %v", rr.Header())
+	}
+
+	rdr.renderInvoked = false
+	req := httpGetRequest("?location=Berlin")
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if err := checkResponse(rr.Code, http.StatusNotModified, rr.Body.String(), ""); err != nil {
+		t.Error(err)
+	}
+	if rdr.renderInvoked {
+		t.Error("RenderTemplate was not expected to be called for a 304")
+	}
+}
+
+func TestWidgetHandler_MaxAge(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 5}, nil
+		},
+	}
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			return template.New("some template")
+		},
+		renderFunc: func(w io.Writer, tmpl *template.Template, i interface{}) error {
+			return nil
+		},
+	}
+	for name, tc := range map[string]struct {
+		cache    expirer
+		expected string
+	}{
+		"fresh":      {fixedExpiry(90 * time.Second), "public, max-age=90"},
+		"expired":    {fixedExpiry(-time.Second), "public, max-age=0"},
+		"not cached": {weather.NewCache(forecaster, time.Minute), "public, max-age=0"},
+	} {
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(widgetHandler(rdr, forecaster, "", tc.cache)).ServeHTTP(rr, httpGetRequest("?location=berlin"))
+		if cc := rr.Header().Get("Cache-Control"); cc != tc.expected {
+			t.Errorf("%s: expected %q but got %q", name, tc.expected, cc)
+		}
+	}
+}
+
+func TestWidgetHandler_Theme(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 5}, nil
+		},
+	}
+	rdr := &rendererMock{
+		themes: []string{"dark", "minimal"},
+		buildFunc: func(layouts ...string) *template.Template {
+			return template.New("default")
+		},
+		renderFunc: func(w io.Writer, tmpl *template.Template, i interface{}) error {
+			w.Write([]byte(tmpl.Name()))
+			return nil
+		},
+	}
+
+	h := http.HandlerFunc(widgetHandler(rdr, forecaster, "", fixedExpiry(time.Minute)))
+	etags := map[string]bool{}
+	for query, expected := range map[string]string{
+		"?location=Berlin":               "default",
+		"?location=Berlin&theme=dark":    "dark",
+		"?location=Berlin&theme=minimal": "minimal",
+		"?location=Berlin&theme=neon":    "default",
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httpGetRequest(query))
+		if err := checkResponse(rr.Code, http.StatusOK, rr.Body.String(), expected); err != nil {
+			t.Errorf("%s: %s", query, err)
+		}
+		etags[rr.Header().Get("ETag")] = true
+	}
+	if len(etags) != 3 {
+		t.Errorf("expected an ETag per theme but got %v", etags)
+	}
+}
+
+func TestWidgetHandler_AssetsChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stylesheet := filepath.Join(dir, "widget.css")
+	ioutil.WriteFile(stylesheet, []byte("body { color: black }"), 0644)
+	before, err := assets.Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(stylesheet, []byte("body { color: white }"), 0644)
+	after, err := assets.Build(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 5}, nil
+		},
+	}
+	rdr := &rendererMock{
+		buildFunc: func(layouts ...string) *template.Template {
+			return template.New("some template")
+		},
+		renderFunc: func(w io.Writer, tmpl *template.Template, i interface{}) error {
+			w.Write([]byte("all good"))
+			return nil
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(widgetHandler(rdr, forecaster, before.Digest(), fixedExpiry(time.Minute))).
+		ServeHTTP(rr, httpGetRequest("?location=Berlin"))
+
+	req := httpGetRequest("?location=Berlin")
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(widgetHandler(rdr, forecaster, after.Digest(), fixedExpiry(time.Minute))).ServeHTTP(rr, req)
+
+	if err := checkResponse(rr.Code, http.StatusOK, rr.Body.String(), "all good"); err != nil {
+		t.Error(err)
+	}
+}
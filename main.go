@@ -1,51 +1,244 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/wwgberlin/go-weather-widget/assets"
+	"github.com/wwgberlin/go-weather-widget/character"
+	"github.com/wwgberlin/go-weather-widget/picture"
 	"github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/scenario"
 	"github.com/wwgberlin/go-weather-widget/weather/worldweatheronline"
 )
 
-func validateInput(port string, apiKey string) bool {
+func validateInput(port string, apiKey string, keyless bool) bool {
 	var p int16
 	if _, err := fmt.Sscanf(port, "%d", &p); err != nil {
 		return false
 	}
 
-	if apiKey == "" {
+	if apiKey == "" && !keyless {
 		return false
 	}
 
 	return true
 }
 
+func splitKeys(apiKeys string) []string {
+	var keys []string
+	for _, k := range strings.Split(apiKeys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func main() {
 	const (
 		layoutsPath        = "./tpl/templates"
 		layoutTemplateName = "layout"
+		badgeTemplateName  = "badge"
+		errorTemplateName  = "error"
+		staticPath         = "./public/static"
+		charactersPath     = staticPath + "/characters"
+		compressMinSize    = 1024
 	)
 
 	port := flag.String("port", "8080", "Optional: 4 bytes port")
-	apiKey := flag.String("api_key", "", "Required")
+	apiKey := flag.String("api_key", "", "Required: comma separated API keys, used round-robin")
+	cacheTTL := flag.Duration("cache_ttl", 30*time.Minute, "Optional: time forecasts are cached")
+	prewarmTop := flag.Int("prewarm_top", 50, "Optional: number of popular locations refreshed ahead of expiry, 0 disables")
+	prewarmBudget := flag.Int("prewarm_budget", 20, "Optional: max upstream calls per minute made to prewarm locations")
+	quotaLimit := flag.Int("quota_limit", 500, "Optional: daily calls allowed per API key, 0 disables accounting")
+	quotaThreshold := flag.Float64("quota_threshold", 0.95, "Optional: share of the daily quota after which only the cache is used")
+	quotaFile := flag.String("quota_file", "wwo_quota.json", "Optional: file the quota counters are kept in")
+	apiURL := flag.String("api_url", "", "Optional: URL of a server speaking the World Weather Online API, e.g. cmd/fakeweather")
+	offline := flag.Bool("offline", false, "Optional: answer from the recorded fixtures instead of calling the API")
+	record := flag.Bool("record", false, "Optional: record the API responses as fixtures")
+	scenarioPath := flag.String("scenario", "", "Optional: answer from a scenario file instead of calling the API, e.g. ./scenarios/outfits.json")
+	fixtures := flag.String("fixtures", "./weather/worldweatheronline/testdata/fixtures", "Optional: directory of the recorded fixtures")
+	adminToken := flag.String("admin_token", "", "Optional: token protecting /admin, admin is disabled without it")
+	characterName := flag.String("character", "gopher", "Optional: character pack dressed by the widget, one of the directories in ./public/static/characters")
+	theme := flag.String("theme", "light", "Optional: theme of the widget unless the theme parameter asks for another, one of the directories in ./tpl/templates/themes")
+	errorTemplate := flag.String("error_template", "", "Optional: standalone template of the error pages defining \"error\", instead of ./tpl/templates/error.tmpl rendered through the layout")
+	embedOrigins := flag.String("embed_origins", "*", "Optional: origins allowed to embed the widget in a frame, e.g. 'https://example.com https://*.example.org'")
+	tlsCert := flag.String("tls_cert", "", "Optional: certificate file to serve HTTPS with, reloaded when it changes")
+	tlsKey := flag.String("tls_key", "", "Optional: key file of -tls_cert")
+	acmeDomains := flag.String("acme_domains", "", "Optional: comma separated domains to obtain certificates for from an ACME CA")
+	acmeDirectory := flag.String("acme_directory", "", "Optional: directory URL of the ACME CA, Let's Encrypt by default")
+	acmeCache := flag.String("acme_cache", "acme_cache", "Optional: directory keeping the ACME certificates and account key")
+	acmeEmail := flag.String("acme_email", "", "Optional: contact email of the ACME account")
+	httpRedirect := flag.String("http_redirect", "", "Optional: address of a plain HTTP listener redirecting to HTTPS, e.g. :80")
 	flag.Parse()
 
-	if !validateInput(*port, *apiKey) {
+	if !validateInput(*port, *apiKey, *offline || *scenarioPath != "") {
 		flag.Usage()
 		return
 	}
+	if (*tlsCert == "") != (*tlsKey == "") || (*tlsCert != "" && *acmeDomains != "") {
+		log.Fatal("-tls_cert and -tls_key go together and exclude -acme_domains")
+	}
+	if *httpRedirect != "" && *tlsCert == "" && *acmeDomains == "" {
+		log.Fatal("-http_redirect redirects to HTTPS and needs -tls_cert or -acme_domains")
+	}
+
+	manifest, err := assets.Build(staticPath, "/static/")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	themes, err := tpl.LoadThemes(layoutsPath, staticPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, ok := themes[*theme]; !ok {
+		log.Fatalf("unknown theme %q", *theme)
+	}
+
+	pack, err := character.Load(filepath.Join(charactersPath, *characterName))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	helpers := template.FuncMap{}
+	for name, fn := range tpl.DefaultHelpers {
+		helpers[name] = fn
+	}
+	helpers["asset"] = manifest.URL
+	helpers["dress"] = pack.Dress
+	helpers["character"] = characterStylesheets(manifest, pack, themes)
 
 	rdr := tpl.NewRenderer(layoutTemplateName)
+	rdr.Helpers = helpers
+	rdr.Themes, rdr.DefaultTheme = themes, *theme
+	pages, err := tpl.NewSet(rdr, layoutsPath, map[string]string{
+		"index.tmpl":  layoutTemplateName,
+		"widget.tmpl": layoutTemplateName,
+		"admin.tmpl":  layoutTemplateName,
+		"badge.tmpl":  badgeTemplateName,
+		"error.tmpl":  layoutTemplateName,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	rdr.ErrorTemplate = pages.Template("error.tmpl")
+	if *errorTemplate != "" {
+		errorRdr := tpl.NewRenderer(errorTemplateName)
+		errorRdr.Helpers = helpers
+		rdr.ErrorTemplate = errorRdr.BuildTemplate(*errorTemplate)
+	}
 
-	http.HandleFunc("/", indexHandler(layoutsPath, rdr))
-	http.HandleFunc("/weather", widgetHandler(layoutsPath, rdr, worldweatheronline.New(*apiKey)))
+	var wwoOpts []worldweatheronline.Option
+	switch {
+	case *offline:
+		*apiKey, *quotaLimit = "offline", 0
+		wwoOpts = append(wwoOpts, worldweatheronline.WithTransport(&worldweatheronline.ReplayTransport{Dir: *fixtures}))
+	case *record:
+		wwoOpts = append(wwoOpts, worldweatheronline.WithTransport(&worldweatheronline.RecordingTransport{Dir: *fixtures}))
+	}
+	if *apiURL != "" {
+		wwoOpts = append(wwoOpts, worldweatheronline.WithAPIURL(*apiURL))
+	}
+	if *quotaLimit > 0 {
+		quota, err := worldweatheronline.NewQuota(*quotaFile, *quotaLimit, *quotaThreshold)
+		if err != nil {
+			log.Fatal(err)
+		}
+		wwoOpts = append(wwoOpts, worldweatheronline.WithQuota(quota))
+	}
+
+	wwo := worldweatheronline.New(splitKeys(*apiKey), wwoOpts...)
+	var source weather.Forecaster = wwo
+	ttl := *cacheTTL
+	if *scenarioPath != "" {
+		f, err := scenario.Load(*scenarioPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		source = f
+		// cache a step of a timeline no longer than it is shown
+		if f.Interval() < ttl {
+			ttl = f.Interval()
+		}
+	}
 
-	http.Handle("/images/", http.StripPrefix("/", http.FileServer(http.Dir("./public/static"))))
-	http.Handle("/styles/", http.StripPrefix("/", http.FileServer(http.Dir("./public/static"))))
+	upstream := weather.NewMonitor(source, 20)
+	cache := weather.NewCache(weather.NewAlerter(upstream, weather.DefaultThresholds), ttl)
+	popular := weather.NewPopularity()
+
+	if *prewarmTop > 0 {
+		go weather.NewPrewarmer(cache, popular, *prewarmTop, *prewarmBudget).Run(nil)
+	}
 
-	log.Printf("Application serving on http://localhost:%s ...", *port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", *port), nil))
+	http.HandleFunc("/", indexHandler(pages))
+	layers, err := picture.Load(pack, 200)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	forecaster := popular.Tracking(cache)
+	assetVersion := manifest.Digest()
+	pictureVersion := assetVersion + "/" + pack.ID
+	http.HandleFunc("/weather", widgetHandler(pages, forecaster, assetVersion, cache))
+	http.HandleFunc("/weather.png", pictureHandler(pages, forecaster, "image/png", layers.PNG, pictureVersion, cache))
+	http.HandleFunc("/weather.svg", pictureHandler(pages, forecaster, "image/svg+xml", layers.SVG, pictureVersion, cache))
+	http.HandleFunc("/badge", badgeHandler(pages, forecaster, cache))
+
+	if *adminToken != "" {
+		http.HandleFunc("/admin", requireToken(*adminToken, adminHandler(pages, cache, upstream, wwo, popular, *prewarmTop)))
+		http.HandleFunc("/admin/locations", requireToken(*adminToken, locationsHandler(popular, cache, *prewarmTop)))
+		http.HandleFunc("/admin/purge", requireToken(*adminToken, purgeHandler(cache)))
+		http.HandleFunc("/admin/refresh", requireToken(*adminToken, refreshHandler(cache)))
+	}
+
+	http.Handle("/static/", manifest)
+	http.Handle("/images/", legacyImagesHandler(manifest))
+	http.Handle("/styles/", staticHandler(staticPath))
+
+	policy := securityPolicy{FrameAncestors: map[string]string{
+		"/weather":         embedAncestors(*embedOrigins),
+		"/admin":           "'none'",
+		"/admin/locations": "'none'",
+		"/admin/purge":     "'none'",
+		"/admin/refresh":   "'none'",
+	}}
+	h := compress(secureHeaders(requestIDs(http.DefaultServeMux), policy), compressMinSize)
+
+	srv := &http.Server{Addr: fmt.Sprintf(":%s", *port), Handler: h}
+	redirect := redirectHandler(*port)
+	switch {
+	case *tlsCert != "":
+		certs, err := newCertReloader(*tlsCert, *tlsKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv.TLSConfig = &tls.Config{GetCertificate: certs.GetCertificate}
+	case *acmeDomains != "":
+		m := acmeManager(splitKeys(*acmeDomains), *acmeDirectory, *acmeCache, *acmeEmail)
+		srv.TLSConfig = m.TLSConfig()
+		redirect = m.HTTPHandler(redirect)
+	}
+
+	if srv.TLSConfig == nil {
+		log.Printf("Application serving on http://localhost:%s ...", *port)
+		log.Fatal(srv.ListenAndServe())
+	}
+
+	if *httpRedirect != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(*httpRedirect, redirect))
+		}()
+	}
+	log.Printf("Application serving on https://localhost:%s ...", *port)
+	log.Fatal(srv.ListenAndServeTLS("", ""))
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+// encoder writes a picture of the gopher wearing the clothes with the
+// caption lines below
+type encoder func(w io.Writer, clothes []string, caption ...string) error
+
+// pictureHandler renders the widget for the requested location as a single
+// image with the given content type, for consumers that can't run HTML.
+// version identifies the assets and the character pack the image is drawn
+// from, so that the ETag changes with them.
+func pictureHandler(rdr errorRenderer, forecaster forecaster, contentType string, encode encoder, version string, cache expirer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := forecast(forecaster, r)
+		if err != nil {
+			renderError(w, r, rdr, err)
+			return
+		}
+
+		if notModified(w, r, newETag(contentType, version, *c), freshFor(cache, r)) {
+			return
+		}
+
+		var b bytes.Buffer
+		if err := encode(&b, tpl.Clothes(c.Condition, c.Celsius, c.IsDay),
+			c.Location, c.Description, fmt.Sprintf("%d°C", c.Celsius)); err != nil {
+			uncached(w)
+			renderError(w, r, rdr, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		b.WriteTo(w)
+	}
+}
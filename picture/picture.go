@@ -0,0 +1,208 @@
+// Package picture renders the dressed character and a caption as a single
+// PNG or SVG image, for consumers that can't run HTML and CSS.
+package picture
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/wwgberlin/go-weather-widget/character"
+)
+
+const (
+	textScale  = 2
+	lineHeight = (glyphHeight + 3) * textScale
+	padding    = 8
+)
+
+var (
+	background = color.White
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+)
+
+// baseName is the layer of the base art, garments are named after their
+// pack garment
+const baseName = ""
+
+// Layers are the character and its garments scaled down to the size of
+// the picture
+type Layers struct {
+	pack          *character.Pack
+	width, height int
+	base          *image.RGBA
+	pieces        map[string]*image.RGBA
+	encoded       map[string][]byte
+}
+
+// Load reads the base art and the garments of the character pack, scaled
+// to the given width
+func Load(pack *character.Pack, width int) (*Layers, error) {
+	l := &Layers{
+		pack:    pack,
+		width:   width,
+		pieces:  map[string]*image.RGBA{},
+		encoded: map[string][]byte{},
+	}
+
+	files := map[string]string{baseName: pack.Base}
+	for name, g := range pack.Garments {
+		files[name] = g.Image
+	}
+	for name, f := range files {
+		img, err := loadScaled(filepath.Join(pack.Dir, f), width)
+		if err != nil {
+			return nil, err
+		}
+
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			return nil, err
+		}
+		l.encoded[name] = b.Bytes()
+
+		if name == baseName {
+			l.base = img
+			l.height = img.Bounds().Dy()
+		} else {
+			l.pieces[name] = img
+		}
+	}
+	return l, nil
+}
+
+// PNG writes the character wearing the clothes with the caption lines below
+func (l *Layers) PNG(w io.Writer, clothes []string, caption ...string) error {
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height+captionHeight(caption)))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	draw.Draw(img, l.base.Bounds(), l.base, image.Point{}, draw.Over)
+	for _, piece := range l.order(clothes) {
+		draw.Draw(img, l.base.Bounds(), l.pieces[piece], image.Point{}, draw.Over)
+	}
+
+	y := l.height + padding
+	for _, line := range caption {
+		scale := textScale
+		if textWidth(line, scale) > l.width-2*padding {
+			scale = 1
+		}
+		line = truncate(line, scale, l.width-2*padding)
+		drawText(img, image.Pt((l.width-textWidth(line, scale))/2, y), line, scale, foreground)
+		y += lineHeight
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG writes the character wearing the clothes with the caption lines below.
+// The layers are embedded so that the image has no external references.
+func (l *Layers) SVG(w io.Writer, clothes []string, caption ...string) error {
+	height := l.height + captionHeight(caption)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		l.width, height, l.width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, l.width, height)
+
+	for _, name := range append([]string{baseName}, l.order(clothes)...) {
+		fmt.Fprintf(&b, `<image width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`,
+			l.width, l.height, base64.StdEncoding.EncodeToString(l.encoded[name]))
+	}
+
+	y := l.height + padding + glyphHeight*textScale
+	for _, line := range caption {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-family="sans-serif" font-size="14" fill="#333">`,
+			l.width/2, y)
+		if err := xml.EscapeText(&b, []byte(line)); err != nil {
+			return err
+		}
+		b.WriteString(`</text>`)
+		y += lineHeight
+	}
+	b.WriteString(`</svg>`)
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// order returns the garments of the pack dressing the clothes in
+// drawing order
+func (l *Layers) order(clothes []string) []string {
+	return l.pack.Dress(clothes)
+}
+
+func captionHeight(caption []string) int {
+	if len(caption) == 0 {
+		return 0
+	}
+	return len(caption)*lineHeight + 2*padding
+}
+
+// truncate shortens s until it fits into width
+func truncate(s string, scale, width int) string {
+	r := []rune(s)
+	for len(r) > 0 && textWidth(string(r), scale) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r)
+}
+
+func loadScaled(path string, width int) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	src, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %s", path, err)
+	}
+
+	sb := src.Bounds()
+	height := sb.Dy() * width / sb.Dx()
+	return scale(src, width, height), nil
+}
+
+// scale shrinks src to w x h, averaging the pixels each destination pixel
+// covers
+func scale(src image.Image, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, sb.Dx(), sb.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, sb.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sb.Dy()/h, (y+1)*sb.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sb.Dx()/w, (x+1)*sb.Dx()/w
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				i := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(rgba.Pix[i])
+					g += uint32(rgba.Pix[i+1])
+					b += uint32(rgba.Pix[i+2])
+					a += uint32(rgba.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
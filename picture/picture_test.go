@@ -0,0 +1,95 @@
+package picture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/character"
+)
+
+func TestLayers(t *testing.T) {
+	pack, err := character.Load("../public/static/characters/gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load(pack, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("PNG", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := l.PNG(&b, []string{"umbrella", "crown"}, "Berlin", "Light rain", "9°C"); err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := png.Decode(&b)
+		if err != nil {
+			t.Fatalf("expected a valid PNG. %v", err)
+		}
+		if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != 100 || h != l.height+captionHeight(make([]string, 3)) {
+			t.Errorf("unexpected picture size %dx%d", w, h)
+		}
+	})
+
+	t.Run("SVG", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := l.SVG(&b, []string{"umbrella", "crown"}, "<Berlin>"); err != nil {
+			t.Fatal(err)
+		}
+
+		svg := b.String()
+		if n := strings.Count(svg, "<image "); n != 2 {
+			t.Errorf("expected the base and the umbrella layers but got %d layers", n)
+		}
+		if !strings.Contains(svg, "&lt;Berlin&gt;</text>") {
+			t.Errorf("expected the caption to be escaped but got %s", svg[strings.LastIndex(svg, "<text"):])
+		}
+	})
+}
+
+func TestLayers_Order(t *testing.T) {
+	l := &Layers{pack: &character.Pack{Garments: map[string]character.Garment{
+		"umbrella": {Z: 99}, "scarf": {Z: 90}, "coat": {Z: 30}, "boots": {Z: 10},
+	}}}
+
+	order := l.order([]string{"umbrella", "boots", "scarf", "cape", "coat"})
+	if expected := "boots coat scarf umbrella"; strings.Join(order, " ") != expected {
+		t.Errorf("expected drawing order %s but got %v", expected, order)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if w := textWidth("9°C", 2); w != (3*6-1)*2 {
+		t.Errorf("unexpected text width %d", w)
+	}
+	if s := truncate("Berlin, Germany", 1, 6*6); s != "Berlin" {
+		t.Errorf("expected text to be truncated to 6 glyphs but got %q", s)
+	}
+}
+
+func TestDrawText_NonASCII(t *testing.T) {
+	for s, expected := range map[string]string{
+		"Zürich São":    "ZURICH SAO",
+		"Straße":        "STRASSE",
+		"Łódź":          "LODZ",
+		"Ærøskøbing":    "AEROSKOBING",
+		"Île-de-France": "ILE-DE-FRANCE",
+		"9°C":           "9°C",
+	} {
+		if folded := fold(s); folded != expected {
+			t.Errorf("%s: expected %s but got %s", s, expected, folded)
+		}
+
+		drawn, plain := image.NewRGBA(image.Rect(0, 0, 200, 10)), image.NewRGBA(image.Rect(0, 0, 200, 10))
+		drawText(drawn, image.Point{}, s, 1, color.Black)
+		drawText(plain, image.Point{}, expected, 1, color.Black)
+		if !bytes.Equal(drawn.Pix, plain.Pix) || textWidth(s, 1) != textWidth(expected, 1) {
+			t.Errorf("%s: expected to be drawn like %s", s, expected)
+		}
+	}
+}
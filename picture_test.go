@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestPictureHandler(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 25, Description: "Sunny", Condition: weather.Clear, IsDay: true}, nil
+		},
+	}
+
+	encode := func(w io.Writer, clothes []string, caption ...string) error {
+		if expected := []string{"hat", "sunglasses", "tshirt"}; !reflect.DeepEqual(clothes, expected) {
+			t.Errorf("expected clothes %v but got %v", expected, clothes)
+		}
+		if expected := []string{"Berlin", "Sunny", "25°C"}; !reflect.DeepEqual(caption, expected) {
+			t.Errorf("expected caption %v but got %v", expected, caption)
+		}
+		fmt.Fprint(w, "picture")
+		return nil
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(pictureHandler(&rendererMock{}, forecaster, "image/png", encode, "", fixedExpiry(10*time.Minute))).
+		ServeHTTP(rr, httpGetRequest("/weather.png?location=Berlin"))
+
+	if err := checkResponse(rr.Code, http.StatusOK, rr.Body.String(), "picture"); err != nil {
+		t.Error(err)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected content type image/png but got %s", ct)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, max-age=600" {
+		t.Errorf("expected the picture to be cacheable for 10 minutes but got %s", cc)
+	}
+}
+
+func TestPictureHandler_FailToEncode(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{}, nil
+		},
+	}
+
+	encode := func(w io.Writer, clothes []string, caption ...string) error {
+		fmt.Fprint(w, "half a picture")
+		return errors.New("some error")
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(pictureHandler(&rendererMock{}, forecaster, "image/png", encode, "", fixedExpiry(time.Minute))).
+		ServeHTTP(rr, httpGetRequest("/weather.png?location=Berlin"))
+
+	_, message := errorStatus(errors.New("some error"))
+	if err := checkResponse(rr.Code, http.StatusInternalServerError,
+		strings.TrimSpace(rr.Body.String()), message); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPictureHandler_VersionChanged(t *testing.T) {
+	forecaster := forecasterMock{
+		forecast: func(s string) (*weather.Conditions, error) {
+			return &weather.Conditions{Location: "Berlin", Celsius: 5}, nil
+		},
+	}
+	encode := func(w io.Writer, clothes []string, caption ...string) error {
+		fmt.Fprint(w, "picture")
+		return nil
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(pictureHandler(&rendererMock{}, forecaster, "image/png", encode, "abc/gopher", fixedExpiry(time.Minute))).
+		ServeHTTP(rr, httpGetRequest("/weather.png?location=Berlin"))
+	etag := rr.Header().Get("ETag")
+
+	for version, expected := range map[string]int{
+		"abc/gopher": http.StatusNotModified,
+		"abc/fox":    http.StatusOK,
+		"def/gopher": http.StatusOK,
+	} {
+		req := httpGetRequest("/weather.png?location=Berlin")
+		req.Header.Set("If-None-Match", etag)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(pictureHandler(&rendererMock{}, forecaster, "image/png", encode, version, fixedExpiry(time.Minute))).
+			ServeHTTP(rr, req)
+		if rr.Code != expected {
+			t.Errorf("%s: expected %d but got %d", version, expected, rr.Code)
+		}
+	}
+}
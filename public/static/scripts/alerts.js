@@ -0,0 +1,22 @@
+// Hides the alert banners users dismiss, for the rest of the session
+(function () {
+	var key = function (banner) {
+		return 'dismissed-alert:' + banner.getAttribute('data-alert');
+	};
+
+	document.querySelectorAll('.alert[data-alert]').forEach(function (banner) {
+		try {
+			if (sessionStorage.getItem(key(banner))) {
+				banner.hidden = true;
+				return;
+			}
+		} catch (e) {}
+
+		banner.querySelector('.dismiss').addEventListener('click', function () {
+			banner.hidden = true;
+			try {
+				sessionStorage.setItem(key(banner), '1');
+			} catch (e) {}
+		});
+	});
+})();
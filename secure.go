@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// securityPolicy holds the security headers applied to every response.
+// FrameAncestors lists who may frame the pages, by path; pages not listed
+// may only be framed by the widget itself.
+type securityPolicy struct {
+	FrameAncestors map[string]string
+}
+
+// nonceWriter carries the CSP nonce of a response to the renderer, which
+// exposes it to the templates through the nonce helper (see tpl.Noncer)
+type nonceWriter struct {
+	http.ResponseWriter
+	nonce string
+}
+
+func (w nonceWriter) Nonce() string {
+	return w.nonce
+}
+
+// secureHeaders sets the security headers of p on the responses of h.
+// Every response gets a fresh nonce that inline scripts and styles of the
+// templates have to carry: <style nonce="{{nonce}}">.
+func secureHeaders(h http.Handler, p securityPolicy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := newNonce()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ancestors, ok := p.FrameAncestors[r.URL.Path]
+		if !ok {
+			ancestors = "'self'"
+		}
+
+		hdr := w.Header()
+		hdr.Set("Content-Security-Policy", fmt.Sprintf(
+			"default-src 'self'; script-src 'self' 'nonce-%[1]s'; style-src 'self' 'nonce-%[1]s'; "+
+				"img-src 'self' data:; object-src 'none'; base-uri 'none'; form-action 'self'; frame-ancestors %[2]s",
+			nonce, ancestors))
+		switch ancestors {
+		case "'none'":
+			hdr.Set("X-Frame-Options", "DENY")
+		case "'self'":
+			hdr.Set("X-Frame-Options", "SAMEORIGIN")
+		}
+		hdr.Set("X-Content-Type-Options", "nosniff")
+		hdr.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if isTLS(r) {
+			hdr.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		}
+
+		h.ServeHTTP(nonceWriter{ResponseWriter: w, nonce: nonce}, r)
+	})
+}
+
+// embedAncestors returns the frame-ancestors source list allowing the
+// space or comma separated origins to embed the widget
+func embedAncestors(origins string) string {
+	list := strings.Fields(strings.Replace(origins, ",", " ", -1))
+	if len(list) == 0 {
+		return "'self'"
+	}
+	return "'self' " + strings.Join(list, " ")
+}
+
+// isTLS reports whether the request reached us or the proxy in front of
+// us over TLS
+func isTLS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
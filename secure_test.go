@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	var nonces []string
+	h := secureHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, ok := w.(tpl.Noncer)
+		if !ok {
+			t.Fatal("expected the response writer to carry a nonce")
+		}
+		nonces = append(nonces, n.Nonce())
+	}), securityPolicy{FrameAncestors: map[string]string{
+		"/weather": embedAncestors("https://example.com, https://*.example.org"),
+		"/admin":   "'none'",
+	}})
+
+	get := func(req *http.Request) http.Header {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Header()
+	}
+
+	hdr := get(httpGetRequest("/"))
+	csp := hdr.Get("Content-Security-Policy")
+	for _, expected := range []string{"default-src 'self'", "'nonce-" + nonces[0] + "'", "object-src 'none'", "frame-ancestors 'self'"} {
+		if !strings.Contains(csp, expected) {
+			t.Errorf("expected the CSP to contain %s but got %s", expected, csp)
+		}
+	}
+	for header, expected := range map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Strict-Transport-Security": "",
+	} {
+		if hdr.Get(header) != expected {
+			t.Errorf("expected %s: %q but got %q", header, expected, hdr.Get(header))
+		}
+	}
+
+	get(httpGetRequest("/"))
+	if nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Errorf("expected a fresh nonce per response but got %q and %q", nonces[0], nonces[1])
+	}
+
+	hdr = get(httpGetRequest("/weather?location=Berlin"))
+	if csp := hdr.Get("Content-Security-Policy"); !strings.HasSuffix(csp, "frame-ancestors 'self' https://example.com https://*.example.org") {
+		t.Errorf("expected the widget to be embeddable by the origins but got %s", csp)
+	}
+	if hdr.Get("X-Frame-Options") != "" {
+		t.Errorf("expected no X-Frame-Options on embeddable pages")
+	}
+
+	hdr = get(httpGetRequest("/admin"))
+	if !strings.HasSuffix(hdr.Get("Content-Security-Policy"), "frame-ancestors 'none'") || hdr.Get("X-Frame-Options") != "DENY" {
+		t.Errorf("expected admin never to be framed")
+	}
+
+	req := httpGetRequest("/")
+	req.TLS = &tls.ConnectionState{}
+	if hsts := get(req).Get("Strict-Transport-Security"); hsts != "max-age=31536000; includeSubDomains" {
+		t.Errorf("expected HSTS over TLS but got %q", hsts)
+	}
+	req = httpGetRequest("/")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	if get(req).Get("Strict-Transport-Security") == "" {
+		t.Errorf("expected HSTS behind a TLS terminating proxy")
+	}
+}
+
+func TestEmbedAncestors(t *testing.T) {
+	for origins, expected := range map[string]string{
+		"":                    "'self'",
+		"*":                   "'self' *",
+		"https://a.com":       "'self' https://a.com",
+		"https://a.com,b.org": "'self' https://a.com b.org",
+	} {
+		if got := embedAncestors(origins); got != expected {
+			t.Errorf("%q: expected %s but got %s", origins, expected, got)
+		}
+	}
+}
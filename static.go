@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// staticHandler serves the files in dir under their plain path with an
+// ETag of their content, so that clients revalidate them. Pages link to
+// the fingerprinted URLs of the assets.Manifest instead, which may be
+// cached forever.
+func staticHandler(dir string) http.Handler {
+	fs := http.Dir(dir)
+	fingerprints := &fingerprints{hashes: map[string]fingerprint{}}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		f, err := fs.Open(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		hash, err := fingerprints.of(name, info, f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("ETag", `"`+hash+`"`)
+		w.Header().Set("Cache-Control", "public, no-cache")
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	})
+}
+
+type fingerprint struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// fingerprints remembers the content hash of every file served until
+// the file changes
+type fingerprints struct {
+	mu     sync.Mutex
+	hashes map[string]fingerprint
+}
+
+func (fp *fingerprints) of(name string, info os.FileInfo, f io.ReadSeeker) (string, error) {
+	fp.mu.Lock()
+	cached, ok := fp.hashes[name]
+	fp.mu.Unlock()
+
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.hash, nil
+	}
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))[:12]
+
+	fp.mu.Lock()
+	fp.hashes[name] = fingerprint{modTime: info.ModTime(), size: info.Size(), hash: hash}
+	fp.mu.Unlock()
+	return hash, nil
+}
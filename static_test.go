@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "styles"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "styles", "widget.css"), []byte("body{}"), 0644)
+
+	h := staticHandler(dir)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httpGetRequest("/styles/widget.css"))
+
+	etag := rr.Header().Get("ETag")
+	if err := checkResponse(rr.Code, http.StatusOK, rr.Body.String(), "body{}"); err != nil {
+		t.Error(err)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, no-cache" || etag == "" {
+		t.Errorf("expected files to be revalidated with an ETag but got %s %s", cc, etag)
+	}
+
+	req := httpGetRequest("/styles/widget.css")
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when revalidating but got %d", rr.Code)
+	}
+
+	for _, path := range []string{"/styles/", "/styles/missing.css", "/../static_test.go"} {
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httpGetRequest(path))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 but got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestMatchesETag(t *testing.T) {
+	for header, expected := range map[string]bool{
+		`"abc"`:         true,
+		`W/"abc"`:       true,
+		`"x", "abc"`:    true,
+		`*`:             true,
+		`"abcd"`:        false,
+		``:              false,
+		`"x",W/"other"`: false,
+	} {
+		if matchesETag(header, `"abc"`) != expected {
+			t.Errorf("If-None-Match %s: expected match to be %v", header, expected)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloader serves the certificate in certFile and keyFile and loads
+// it again whenever one of the files changes, so that renewed
+// certificates are picked up without a restart
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertReloader loads the certificate, failing if it can't
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. A certificate that
+// fails to load, e.g. while it is being written, is logged and the
+// previous one kept.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	modTime, err := r.lastModified()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil && !modTime.Equal(r.modTime) {
+		if err := r.load(modTime); err != nil {
+			log.Printf("failed to reload certificate %s: %s", r.certFile, err)
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+// lastModified returns the later modification time of the two files
+func (r *certReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
+
+// acmeManager returns a Manager obtaining certificates for the domains
+// from the ACME CA at directoryURL, Let's Encrypt if it is empty. The
+// certificates and the account key are kept in cacheDir.
+func acmeManager(domains []string, directoryURL, cacheDir, email string) *autocert.Manager {
+	if directoryURL == "" {
+		directoryURL = acme.LetsEncryptURL
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+		Client:     &acme.Client{DirectoryURL: directoryURL},
+	}
+}
+
+// redirectHandler redirects plain HTTP requests to the same URL on the
+// HTTPS port
+func redirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		u := *r.URL
+		u.Scheme, u.Host = "https", host
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newCertificate returns a certificate for the name signed by parent, or
+// self-signed if parent is nil
+func newCertificate(name string, pub crypto.PublicKey, parent *x509.Certificate, parentKey crypto.Signer) ([]byte, error) {
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if parent == nil {
+		parent = tmpl
+	}
+	return x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+}
+
+// writeKeyPair writes a self-signed certificate for the name and its key
+func writeKeyPair(t *testing.T, certFile, keyFile, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := newCertificate(name, key.Public(), nil, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func certName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if _, err := newCertReloader(certFile, keyFile); err == nil {
+		t.Error("expected missing certificates to fail")
+	}
+
+	writeKeyPair(t, certFile, keyFile, "old.example.test")
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := r.GetCertificate(nil)
+	if name := certName(t, cert); name != "old.example.test" {
+		t.Errorf("expected the loaded certificate but got %s", name)
+	}
+
+	later := time.Now().Add(time.Minute)
+	writeKeyPair(t, certFile, keyFile, "new.example.test")
+	os.Chtimes(certFile, later, later)
+	cert, _ = r.GetCertificate(nil)
+	if name := certName(t, cert); name != "new.example.test" {
+		t.Errorf("expected the changed certificate to be reloaded but got %s", name)
+	}
+
+	later = later.Add(time.Minute)
+	ioutil.WriteFile(certFile, []byte("half written"), 0644)
+	os.Chtimes(certFile, later, later)
+	cert, err = r.GetCertificate(nil)
+	if err != nil || certName(t, cert) != "new.example.test" {
+		t.Errorf("expected a broken certificate to keep the previous one")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	for _, tc := range []struct{ port, host, path, expected string }{
+		{"443", "example.com", "/weather?location=Berlin", "https://example.com/weather?location=Berlin"},
+		{"443", "example.com:80", "/", "https://example.com/"},
+		{"8443", "example.com:8080", "/badge", "https://example.com:8443/badge"},
+		{"8443", "[::1]:8080", "/", "https://[::1]:8443/"},
+	} {
+		req := httpGetRequest(tc.path)
+		req.Host = tc.host
+		rr := httptest.NewRecorder()
+		redirectHandler(tc.port).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != tc.expected {
+			t.Errorf("%s%s: expected a redirect to %s but got %d %s", tc.host, tc.path, tc.expected, rr.Code, rr.Header().Get("Location"))
+		}
+	}
+}
+
+// fakeCA is a stand-in for an ACME CA such as Pebble, validating every
+// order right away. It doesn't check the signatures of the requests.
+type fakeCA struct {
+	*httptest.Server
+	key  *ecdsa.PrivateKey
+	root *x509.Certificate
+
+	mu     sync.Mutex
+	nonce  int
+	issued []byte
+}
+
+func newFakeCA(t *testing.T) *fakeCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := newCertificate("fake ACME root", key.Public(), nil, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &fakeCA{key: key, root: root}
+	ca.Server = httptest.NewTLSServer(http.HandlerFunc(ca.serve))
+	return ca
+}
+
+func (ca *fakeCA) serve(w http.ResponseWriter, r *http.Request) {
+	ca.mu.Lock()
+	ca.nonce++
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", ca.nonce))
+	ca.mu.Unlock()
+
+	var jws struct{ Payload string }
+	json.NewDecoder(r.Body).Decode(&jws)
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	switch r.URL.Path {
+	case "/directory":
+		json.NewEncoder(w).Encode(map[string]string{
+			"newNonce":   ca.URL + "/new-nonce",
+			"newAccount": ca.URL + "/new-account",
+			"newOrder":   ca.URL + "/new-order",
+			"revokeCert": ca.URL + "/revoke-cert",
+		})
+	case "/new-nonce":
+		w.WriteHeader(http.StatusOK)
+	case "/new-account":
+		w.Header().Set("Location", ca.URL+"/account/1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"status": "valid"})
+	case "/new-order":
+		w.Header().Set("Location", ca.URL+"/order/1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":         "ready",
+			"authorizations": []string{},
+			"finalize":       ca.URL + "/finalize/1",
+		})
+	case "/finalize/1":
+		var req struct{ CSR string }
+		json.Unmarshal(payload, &req)
+		csrDER, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		der, err := newCertificate(csr.Subject.CommonName, csr.PublicKey, ca.root, ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ca.mu.Lock()
+		ca.issued = der
+		ca.mu.Unlock()
+
+		w.Header().Set("Location", ca.URL+"/order/1")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":      "valid",
+			"finalize":    ca.URL + "/finalize/1",
+			"certificate": ca.URL + "/cert/1",
+		})
+	case "/cert/1":
+		ca.mu.Lock()
+		der := ca.issued
+		ca.mu.Unlock()
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: ca.root.Raw})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestACMEManager(t *testing.T) {
+	ca := newFakeCA(t)
+	defer ca.Close()
+
+	dir, err := ioutil.TempDir("", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := acmeManager([]string{"widget.example.test"}, ca.URL+"/directory", dir, "ops@example.test")
+	m.Client.HTTPClient = ca.Client()
+
+	hello := &tls.ClientHelloInfo{
+		ServerName:       "widget.example.test",
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SupportedCurves:  []tls.CurveID{tls.CurveP256},
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+	}
+	cert, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("expected a certificate from the CA but got %s", err)
+	}
+	if name := certName(t, cert); name != "widget.example.test" {
+		t.Errorf("expected a certificate for the domain but got %s", name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "widget.example.test")); err != nil {
+		t.Errorf("expected the certificate to be cached: %s", err)
+	}
+
+	hello.ServerName = "other.example.test"
+	if _, err := m.GetCertificate(hello); err == nil {
+		t.Error("expected domains not listed to be refused")
+	}
+}
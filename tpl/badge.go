@@ -0,0 +1,123 @@
+package tpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+// TemperatureColor colors the temperatures below Below
+type TemperatureColor struct {
+	Below int
+	Color string
+}
+
+// BadgeTheme colors a badge by temperature. The first entry the
+// temperature is below wins. The last entry colors all warmer
+// temperatures, its Below is ignored.
+type BadgeTheme []TemperatureColor
+
+var (
+	// DefaultBadgeTheme goes from blue for frost to red for heat
+	DefaultBadgeTheme = BadgeTheme{
+		{Below: 0, Color: "#007ec6"},
+		{Below: 10, Color: "#5fb3e0"},
+		{Below: 18, Color: "#97ca00"},
+		{Below: 25, Color: "#dfb317"},
+		{Below: 30, Color: "#fe7d37"},
+		{Below: 0, Color: "#e05d44"},
+	}
+
+	// BadgeThemes are the themes a badge can be requested with
+	BadgeThemes = map[string]BadgeTheme{
+		"default": DefaultBadgeTheme,
+		"mono":    {{Color: "#555"}},
+		"pastel": {
+			{Below: 0, Color: "#8fb8de"},
+			{Below: 18, Color: "#9fd8a4"},
+			{Below: 25, Color: "#f3d98b"},
+			{Below: 0, Color: "#f4a6a0"},
+		},
+	}
+)
+
+// Color returns the color of the temperature
+func (t BadgeTheme) Color(celsius int) string {
+	for _, tc := range t[:len(t)-1] {
+		if celsius < tc.Below {
+			return tc.Color
+		}
+	}
+	return t[len(t)-1].Color
+}
+
+// Badge is a compact shields.io style badge showing a label on the left
+// and an icon with a value on the right
+type Badge struct {
+	Label, Value string
+	Icon         string
+	Color        string
+
+	LabelWidth, ValueWidth, Width int
+	LabelX, IconX, ValueX         int
+}
+
+const (
+	badgePadding   = 5
+	badgeIconWidth = 14
+)
+
+// NewBadge lays out the badge of the conditions at a location
+func NewBadge(location string, celsius int, condition weather.Condition, theme BadgeTheme) Badge {
+	b := Badge{
+		Label: location,
+		Value: fmt.Sprintf("%d°C", celsius),
+		Icon:  badgeIcon(condition),
+		Color: theme.Color(celsius),
+	}
+
+	labelText := badgeTextWidth(b.Label)
+	valueText := badgeTextWidth(b.Value)
+
+	b.LabelWidth = labelText + 2*badgePadding
+	b.ValueWidth = badgeIconWidth + badgePadding + valueText + 2*badgePadding
+	b.Width = b.LabelWidth + b.ValueWidth
+
+	b.LabelX = b.LabelWidth / 2
+	b.IconX = b.LabelWidth + badgePadding
+	b.ValueX = b.IconX + badgeIconWidth + badgePadding + valueText/2
+	return b
+}
+
+// badgeIcon picks the icon for the condition
+func badgeIcon(condition weather.Condition) string {
+	switch condition {
+	case weather.Sleet, weather.Snow:
+		return "snow"
+	case weather.Drizzle, weather.Rain, weather.Thunder:
+		return "rain"
+	case weather.Cloudy, weather.Fog:
+		return "cloud"
+	default:
+		return "sun"
+	}
+}
+
+// badgeTextWidth estimates the width of s in 11px Verdana
+func badgeTextWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("iljtfI.,:;'!| ", r):
+			w += 4
+		case strings.ContainsRune("mwMW", r):
+			w += 10
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			w += 8
+		default:
+			w += 7
+		}
+	}
+	return w
+}
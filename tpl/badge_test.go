@@ -0,0 +1,71 @@
+package tpl_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestBadgeTheme_Color(t *testing.T) {
+	theme := BadgeTheme{
+		{Below: 0, Color: "blue"},
+		{Below: 20, Color: "green"},
+		{Color: "red"},
+	}
+
+	for celsius, expected := range map[int]string{-5: "blue", 0: "green", 19: "green", 20: "red", 40: "red"} {
+		if c := theme.Color(celsius); c != expected {
+			t.Errorf("expected %s at %d°C but got %s", expected, celsius, c)
+		}
+	}
+}
+
+func TestNewBadge(t *testing.T) {
+	b := NewBadge("Berlin", 9, weather.Rain, DefaultBadgeTheme)
+
+	if b.Value != "9°C" || b.Icon != "rain" || b.Color != DefaultBadgeTheme.Color(9) {
+		t.Errorf("unexpected badge %+v", b)
+	}
+	if b.Width != b.LabelWidth+b.ValueWidth || b.ValueX <= b.IconX || b.IconX <= b.LabelWidth {
+		t.Errorf("unexpected badge layout %+v", b)
+	}
+
+	for condition, icon := range map[weather.Condition]string{
+		weather.Clear: "sun", weather.Cloudy: "cloud", weather.Drizzle: "rain", weather.Snow: "snow", weather.Unknown: "sun",
+	} {
+		if b := NewBadge("Berlin", 0, condition, DefaultBadgeTheme); b.Icon != icon {
+			t.Errorf("expected icon %s for %s but got %s", icon, condition, b.Icon)
+		}
+	}
+}
+
+func TestTemplateBadge(t *testing.T) {
+	var b bytes.Buffer
+	rdr := testRenderer("badge", nil)
+	tmpl := rdr.BuildTemplate("./templates/badge.tmpl")
+
+	if err := rdr.RenderTemplate(&b, tmpl, NewBadge("<Berlin>", 30, weather.Clear, DefaultBadgeTheme)); err != nil {
+		t.Fatalf("Template badge.tmpl was expected to execute without errors. %v", err)
+	}
+
+	d := xml.NewDecoder(&b)
+	texts := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Badge was expected to be valid XML. %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "text" {
+			texts++
+		}
+	}
+	if texts != 2 {
+		t.Errorf("expected a label and a value text but got %d texts", texts)
+	}
+}
@@ -0,0 +1,209 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+// The helpers taking options take the value last, so that it can be
+// piped into them: {{.celsius | temperature "F"}}.
+
+// Temperature formats degrees Celsius in the unit "C", "F" or "K",
+// falling back to Celsius for other units
+func Temperature(unit string, celsius int) string {
+	switch strings.ToUpper(unit) {
+	case "F":
+		return fmt.Sprintf("%d°F", int(math.Floor(float64(celsius)*9/5+32+0.5)))
+	case "K":
+		return fmt.Sprintf("%d K", celsius+273)
+	default:
+		return fmt.Sprintf("%d°C", celsius)
+	}
+}
+
+// RelativeTime describes when t was, or will be, as seen at now, e.g.
+// "just now", "5 min ago" or "in 2 hours"
+func RelativeTime(now, t time.Time) string {
+	d := now.Sub(t)
+	format := "%s ago"
+	if d < 0 {
+		d, format = -d, "in %s"
+	}
+
+	var span string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		span = fmt.Sprintf("%d min", int(d/time.Minute))
+	case d < 24*time.Hour:
+		span = Pluralize("hour", "hours", int(d/time.Hour))
+	default:
+		span = Pluralize("day", "days", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf(format, span)
+}
+
+// windArrows point where winds from the north, north-east, ... blow to
+var windArrows = []string{"↓", "↙", "←", "↖", "↑", "↗", "→", "↘"}
+
+// WindArrow returns the arrow pointing where the wind coming from the
+// direction in degrees, as given in forecasts, blows to
+func WindArrow(degrees int) string {
+	sector := int(math.Floor(float64(degrees)/45+0.5)) % len(windArrows)
+	if sector < 0 {
+		sector += len(windArrows)
+	}
+	return windArrows[sector]
+}
+
+// locale holds the formats of a language
+type locale struct {
+	decimal, group string
+	months         [12]string
+	date           func(day int, month string, year int) string
+}
+
+// locales are the languages of Number and Date, English is the fallback
+var locales = map[string]locale{
+	"en": {
+		decimal: ".", group: ",",
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		date: func(d int, m string, y int) string {
+			return fmt.Sprintf("%s %d, %d", m, d, y)
+		},
+	},
+	"de": {
+		decimal: ",", group: ".",
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		date: func(d int, m string, y int) string {
+			return fmt.Sprintf("%d. %s %d", d, m, y)
+		},
+	},
+	"fr": {
+		decimal: ",", group: " ",
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		date: func(d int, m string, y int) string {
+			return fmt.Sprintf("%d %s %d", d, m, y)
+		},
+	},
+	"es": {
+		decimal: ",", group: ".",
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		date: func(d int, m string, y int) string {
+			return fmt.Sprintf("%d de %s de %d", d, m, y)
+		},
+	},
+}
+
+// lookupLocale returns the locale of the language of a tag like "de" or
+// "de-AT", or English
+func lookupLocale(tag string) locale {
+	lang := strings.ToLower(strings.SplitN(strings.Replace(tag, "_", "-", -1), "-", 2)[0])
+	if l, ok := locales[lang]; ok {
+		return l
+	}
+	return locales["en"]
+}
+
+// Number formats the number with the decimals and the separators of the
+// language, e.g. 1234.5 becomes "1.234,5" in "de"
+func Number(language string, decimals int, v interface{}) (string, error) {
+	var f float64
+	switch n := v.(type) {
+	case int:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case float32:
+		f = float64(n)
+	case float64:
+		f = n
+	default:
+		return "", fmt.Errorf("number: expected a number but got %T", v)
+	}
+
+	l := lookupLocale(language)
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], l.decimal+s[i+1:]
+	}
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + l.group + whole[i:]
+	}
+
+	sign := ""
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		sign = "-"
+	}
+	return sign + whole + fraction, nil
+}
+
+// Date formats the day of t in the language, e.g. "19. Oktober 2026"
+func Date(language string, t time.Time) string {
+	l := lookupLocale(language)
+	return l.date(t.Day(), l.months[t.Month()-1], t.Year())
+}
+
+// Default returns the value unless it is empty like nil, "", 0 or an
+// empty list, otherwise the fallback
+func Default(fallback, value interface{}) interface{} {
+	if truth, ok := template.IsTrue(value); !ok || !truth {
+		return fallback
+	}
+	return value
+}
+
+// Pluralize returns the count with the singular or plural noun, e.g.
+// "1 hour" or "3 hours"
+func Pluralize(singular, plural string, count int) string {
+	if count == 1 || count == -1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+// IconsDir is the directory of the condition icons below the assets
+const IconsDir = "icons"
+
+// Icon returns the asset name of the icon of the condition, that of
+// weather.Unknown for conditions out of range
+func Icon(condition weather.Condition) string {
+	return path.Join(IconsDir, condition.String()+".svg")
+}
+
+// Title upper cases the first letter of every word. Unlike strings.Title
+// it doesn't start a new word after apostrophes, "it's" becomes "It's",
+// and uses the title case of letters such as "ǆ".
+func Title(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	prev := ' '
+	for _, r := range s {
+		if isWordStart(prev) && unicode.IsLetter(r) {
+			r = unicode.ToTitle(r)
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// isWordStart reports whether a word starts after the rune
+func isWordStart(prev rune) bool {
+	switch prev {
+	case '\'', '’', utf8.RuneError:
+		return false
+	}
+	return unicode.IsSpace(prev) || unicode.IsPunct(prev) || unicode.IsSymbol(prev)
+}
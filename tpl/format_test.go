@@ -0,0 +1,199 @@
+package tpl_test
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestTemperature(t *testing.T) {
+	for _, tc := range []struct {
+		unit     string
+		celsius  int
+		expected string
+	}{
+		{"C", 21, "21°C"},
+		{"F", 22, "72°F"},
+		{"f", -40, "-40°F"},
+		{"F", -18, "0°F"},
+		{"K", 0, "273 K"},
+		{"", 5, "5°C"},
+		{"R", 5, "5°C"},
+	} {
+		if s := Temperature(tc.unit, tc.celsius); s != tc.expected {
+			t.Errorf("%d in %q: expected %q but got %q", tc.celsius, tc.unit, tc.expected, s)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "just now"},
+		{-59 * time.Second, "just now"},
+		{-5 * time.Minute, "5 min ago"},
+		{-time.Hour, "1 hour ago"},
+		{-3*time.Hour - 59*time.Minute, "3 hours ago"},
+		{-49 * time.Hour, "2 days ago"},
+		{5*time.Minute + time.Second, "in 5 min"},
+		{24 * time.Hour, "in 1 day"},
+	} {
+		if s := RelativeTime(now, now.Add(tc.d)); s != tc.expected {
+			t.Errorf("%s: expected %q but got %q", tc.d, tc.expected, s)
+		}
+	}
+}
+
+func TestWindArrow(t *testing.T) {
+	for degrees, expected := range map[int]string{
+		0: "↓", 22: "↓", 23: "↙", 45: "↙", 90: "←", 180: "↑",
+		270: "→", 315: "↘", 350: "↓", 360: "↓", 720: "↓", -90: "→",
+	} {
+		if s := WindArrow(degrees); s != expected {
+			t.Errorf("%d°: expected %s but got %s", degrees, expected, s)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for _, tc := range []struct {
+		language string
+		decimals int
+		v        interface{}
+		expected string
+	}{
+		{"en", 1, 1234.56, "1,234.6"},
+		{"de", 1, 1234.56, "1.234,6"},
+		{"de-AT", 0, 1234567, "1.234.567"},
+		{"fr", 2, float32(0.5), "0,50"},
+		{"es", 0, int64(-1013), "-1.013"},
+		{"en", 0, -0.2, "0"},
+		{"xx", 0, 999, "999"},
+		{"", 2, -1000.0, "-1,000.00"},
+	} {
+		s, err := Number(tc.language, tc.decimals, tc.v)
+		if err != nil {
+			t.Errorf("%v in %q: unexpected error %v", tc.v, tc.language, err)
+		} else if s != tc.expected {
+			t.Errorf("%v in %q: expected %q but got %q", tc.v, tc.language, tc.expected, s)
+		}
+	}
+
+	if _, err := Number("en", 0, "12"); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+func TestDate(t *testing.T) {
+	day := time.Date(2026, time.October, 19, 23, 0, 0, 0, time.UTC)
+	for language, expected := range map[string]string{
+		"en":    "October 19, 2026",
+		"de":    "19. Oktober 2026",
+		"fr":    "19 octobre 2026",
+		"es_ES": "19 de octubre de 2026",
+		"nl":    "October 19, 2026",
+	} {
+		if s := Date(language, day); s != expected {
+			t.Errorf("%s: expected %q but got %q", language, expected, s)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	for _, tc := range []struct {
+		value, expected interface{}
+	}{
+		{nil, "fallback"},
+		{"", "fallback"},
+		{0, "fallback"},
+		{[]string{}, "fallback"},
+		{"Berlin", "Berlin"},
+		{-1, -1},
+	} {
+		if v := Default("fallback", tc.value); v != tc.expected {
+			t.Errorf("%#v: expected %v but got %v", tc.value, tc.expected, v)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	for count, expected := range map[int]string{
+		0: "0 hours", 1: "1 hour", 2: "2 hours", -1: "-1 hour",
+	} {
+		if s := Pluralize("hour", "hours", count); s != expected {
+			t.Errorf("%d: expected %q but got %q", count, expected, s)
+		}
+	}
+}
+
+func TestIcon(t *testing.T) {
+	for condition, expected := range map[weather.Condition]string{
+		weather.Clear: "icons/clear.svg", weather.PartlyCloudy: "icons/partly-cloudy.svg",
+		weather.Unknown: "icons/unknown.svg", -1: "icons/unknown.svg", 100: "icons/unknown.svg",
+	} {
+		if s := Icon(condition); s != expected {
+			t.Errorf("%d: expected %s but got %s", condition, expected, s)
+		}
+	}
+
+	for _, condition := range append(weather.AllConditions(), weather.Unknown) {
+		if _, err := os.Stat(filepath.Join("../public/static", Icon(condition))); err != nil {
+			t.Errorf("%s: expected the icon to be bundled. %v", condition, err)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	for s, expected := range map[string]string{
+		"berlin":           "Berlin",
+		"new york":         "New York",
+		"saint-étienne":    "Saint-Étienne",
+		"rio de janeiro":   "Rio De Janeiro",
+		"it's raining":     "It's Raining",
+		"o’higgins":        "O’higgins",
+		"ǆakovo":           "ǅakovo",
+		"(münchen)":        "(München)",
+		"mcDonald":         "McDonald",
+		"  östersund\tåre": "  Östersund\tÅre",
+		"":                 "",
+	} {
+		if title := Title(s); title != expected {
+			t.Errorf("%q: expected %q but got %q", s, expected, title)
+		}
+	}
+}
+
+func TestDefaultHelpers_Format(t *testing.T) {
+	tmpl := template.Must(template.New("format").Funcs(DefaultHelpers).Parse(
+		`{{.celsius | temperature "F"}} {{.degrees | windArrow}} {{.pressure | number "de" 1}} ` +
+			`{{.location | default "Berlin" | title}} {{.hours | pluralize "hour" "hours"}} ` +
+			`{{.condition | icon}} {{.day | date "de"}} {{.updated | ago}}`))
+
+	var b bytes.Buffer
+	err := tmpl.Execute(&b, map[string]interface{}{
+		"celsius":   22,
+		"degrees":   45,
+		"pressure":  1013.25,
+		"location":  "",
+		"hours":     3,
+		"condition": weather.PartlyCloudy,
+		"day":       time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		"updated":   time.Now().Add(-5*time.Minute - time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "72°F ↙ 1.013,2 Berlin 3 hours icons/partly-cloudy.svg 19. Oktober 2026 5 min ago"
+	if b.String() != expected {
+		t.Errorf("expected %q but got %q", expected, b.String())
+	}
+}
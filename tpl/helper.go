@@ -1,19 +1,19 @@
 package tpl
 
 import (
-	"regexp"
+	"github.com/wwgberlin/go-weather-widget/weather"
 )
 
-var umbrellaStr = regexp.MustCompile("[[R|r]ain|[D|d]rizzl|[S|s]leet")
-
-func clothes(weatherDesc string, celsius int) (clothes []string) {
-	if umbrellaStr.Match([]byte(weatherDesc)) {
+// Clothes returns the pieces the gopher wears in the given weather, no
+// sun protection after sunset
+func Clothes(condition weather.Condition, celsius int, isDay bool) (clothes []string) {
+	if condition.Wet() {
 		clothes = append(clothes, "umbrella")
 	}
-	if celsius > 22 {
+	if celsius > 22 && isDay {
 		clothes = append(clothes, "hat")
 	}
-	if celsius > 20 {
+	if celsius > 20 && isDay {
 		clothes = append(clothes, "sunglasses")
 	}
 	if celsius > 15 {
@@ -0,0 +1,40 @@
+package tpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"github.com/wwgberlin/go-weather-widget/weather/scenario"
+)
+
+// TestOutfitsScenario makes sure that scenarios/outfits.json lets
+// designers see every outfit Clothes can produce
+func TestOutfitsScenario(t *testing.T) {
+	f, err := scenario.Load("../scenarios/outfits.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := map[string]bool{}
+	for _, l := range []string{
+		"freezing", "freezing-rain", "cold", "cold-rain", "cool", "cool-rain",
+		"mild", "mild-rain", "warm", "warm-rain", "hot", "hot-rain", "hot-night",
+	} {
+		c, err := f.Forecast(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		covered[strings.Join(Clothes(c.Condition, c.Celsius, c.IsDay), " ")] = true
+	}
+
+	for celsius := -50; celsius <= 50; celsius++ {
+		for _, condition := range weather.AllConditions() {
+			for _, isDay := range []bool{true, false} {
+				if outfit := strings.Join(Clothes(condition, celsius, isDay), " "); !covered[outfit] {
+					t.Errorf("outfit %q for %s at %d°C (day %v) is missing from the scenario", outfit, condition, celsius, isDay)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+)
+
+// PartialsDir is the directory below the templates holding the partials.
+// Every page gets them, so that it can render them with the component
+// helper:
+//
+//	{{component "location-form" (dict "location" .location)}}
+const PartialsDir = "partials"
+
+// sharedFiles returns the files of the layouts and partials
+func sharedFiles(templatesDir string) []string {
+	var files []string
+	for _, dir := range []string{"layouts", PartialsDir} {
+		// Glob only fails on malformed patterns
+		matches, _ := filepath.Glob(filepath.Join(templatesDir, dir, "*.tmpl"))
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// componentHelper returns the component helper of the template, which
+// renders the named template, usually a partial, with the optional data
+func componentHelper(t *template.Template) func(name string, data ...interface{}) (template.HTML, error) {
+	return func(name string, data ...interface{}) (template.HTML, error) {
+		if len(data) > 1 {
+			return "", fmt.Errorf("component %s: expected one argument but got %d", name, len(data))
+		}
+		var arg interface{}
+		if len(data) == 1 {
+			arg = data[0]
+		}
+
+		var b bytes.Buffer
+		if err := t.ExecuteTemplate(&b, name, arg); err != nil {
+			return "", err
+		}
+		return template.HTML(b.String()), nil
+	}
+}
+
+// Dict builds a map from key value pairs, e.g. the arguments of a
+// component
+func Dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict: expected key value pairs but got %d arguments", len(pairs))
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: expected a string key but got %v", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// List builds a slice of the items, e.g. to range over
+func List(items ...interface{}) []interface{} {
+	return items
+}
@@ -0,0 +1,82 @@
+package tpl_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+)
+
+func TestComponent(t *testing.T) {
+	set, err := NewSet(testRenderer(layoutTemplateName, nil), "./templates", map[string]string{"index.tmpl": layoutTemplateName})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, w := range []interface {
+		Write([]byte) (int, error)
+		String() string
+	}{&bytes.Buffer{}, &nonceBuffer{nonce: "abc"}} {
+		if err := set.RenderTemplate(w, set.Template("index.tmpl"), map[string]interface{}{"location": `"Berlin"`}); err != nil {
+			t.Fatalf("%T: index was expected to render without errors. %v", w, err)
+		}
+
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(w.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := doc.Find(`form[action="/weather"] input[name="location"]`)
+		if v, _ := input.Attr("value"); v != `"Berlin"` {
+			t.Errorf("%T: expected the location form with the location but got %q", w, v)
+		}
+		if p, _ := input.Attr("placeholder"); p != "Location" {
+			t.Errorf("%T: expected the default placeholder but got %q", w, p)
+		}
+		if strings.Contains(w.String(), "&lt;form") {
+			t.Errorf("%T: expected the component not to be escaped again", w)
+		}
+	}
+}
+
+func TestComponent_Errors(t *testing.T) {
+	for _, name := range []string{"component-missing", "component-arguments", "component-failing"} {
+		rdr := testRenderer(name, nil)
+		tmpl := rdr.BuildTemplate("./test/components.tmpl")
+		var b bytes.Buffer
+		if err := rdr.RenderTemplate(&b, tmpl, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDict(t *testing.T) {
+	m, err := Dict("location", "Berlin", "celsius", 12)
+	if err != nil || !reflect.DeepEqual(m, map[string]interface{}{"location": "Berlin", "celsius": 12}) {
+		t.Errorf("unexpected dict %v (%v)", m, err)
+	}
+	if m, err := Dict(); err != nil || len(m) != 0 {
+		t.Errorf("expected an empty dict but got %v (%v)", m, err)
+	}
+	if _, err := Dict("location"); err == nil {
+		t.Error("expected a key without a value to fail")
+	}
+	if _, err := Dict(1, "Berlin"); err == nil {
+		t.Error("expected a key that is no string to fail")
+	}
+}
+
+func TestList(t *testing.T) {
+	rdr := testRenderer("list", nil)
+	tmpl := rdr.BuildTemplate("./test/components.tmpl")
+
+	var b bytes.Buffer
+	if err := rdr.RenderTemplate(&b, tmpl, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<div class="hat"></div><div class="scarf"></div>`; b.String() != expected {
+		t.Errorf("expected %s but got %s", expected, b.String())
+	}
+}
@@ -1,19 +1,82 @@
 package tpl
 
 import (
-	"errors"
+	"bytes"
 	"html/template"
 	"io"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
+// maxPooledBuffer is the capacity up to which render buffers are reused
+const maxPooledBuffer = 64 << 10
+
+var buffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// DefaultHelpers are the helpers of new renderers. Templates built by a
+// renderer also get the component helper, see PartialsDir. The server
+// copies them and replaces the dress and character helpers with those of
+// the character pack: dress returns the garments dressing the clothes in
+// drawing order, character the asset name of the stylesheet putting the
+// artwork of the pack, or that of the theme, on the page.
+//
+// The formatting helpers take the value last, so that it can be piped:
+//
+//	{{.celsius | temperature "F"}}        72°F
+//	{{.updated | ago}}                    5 min ago
+//	{{.windDegree | windArrow}}           ↙
+//	{{.pressure | number "de" 1}}         1.013,2
+//	{{.date | date "fr"}}                 19 octobre 2026
+//	{{.location | default "Berlin"}}      Berlin if the location is empty
+//	{{.hours | pluralize "hour" "hours"}} 3 hours
+//	{{asset (icon .condition)}}           /icons/partly-cloudy.svg
+//	{{.location | title}}                 Saint-Étienne
 var DefaultHelpers = template.FuncMap{
-	"title": strings.Title,
+	"title":       Title,
+	"temperature": Temperature,
+	"ago":         func(t time.Time) string { return RelativeTime(time.Now(), t) },
+	"windArrow":   WindArrow,
+	"number":      Number,
+	"date":        Date,
+	"default":     Default,
+	"pluralize":   Pluralize,
+	"icon":        Icon,
+	"clothes":     Clothes,
+	"asset":       asset,
+	"theme":       func() *Theme { return nil },
+	"dress":       func(clothes []string) []string { return clothes },
+	"character":   func(*Theme) string { return "" },
+	"dict":        Dict,
+	"list":        List,
+}
+
+// Noncer is implemented by writers of responses with a Content-Security-
+// Policy nonce. RenderTemplate adds it to map data as {{$.nonce}}.
+type Noncer interface {
+	Nonce() string
+}
+
+// asset returns the URL of a file under public/static. Register the URL
+// method of an assets.Manifest as "asset" to get fingerprinted URLs.
+func asset(name string) string {
+	return "/" + strings.TrimPrefix(name, "/")
 }
 
 type LayoutRenderer struct {
 	Helpers    template.FuncMap
 	LayoutName string
+
+	// Themes are built by NewSet, DefaultTheme is used when no theme
+	// is asked for
+	Themes       Themes
+	DefaultTheme string
+
+	// ErrorTemplate renders the error pages of RenderError. If it fails,
+	// too, e.g. because the layout is what failed, RenderError falls back
+	// to a plain text error.
+	ErrorTemplate *template.Template
 }
 
 func NewRenderer(layoutName string) *LayoutRenderer {
@@ -23,15 +86,105 @@ func NewRenderer(layoutName string) *LayoutRenderer {
 	}
 }
 
-// BuildTemplate attempts to build a new template given the LayoutName,
+// BuildTemplate builds a new template given the LayoutName,
 // the Helpers FuncMap defined in the renderer, and parses the files.
-// Use template.Must to panic if parse fails.
+// It panics if parsing fails.
 func (r *LayoutRenderer) BuildTemplate(files ...string) *template.Template {
-	return nil
+	return template.Must(r.newTemplate().ParseFiles(files...))
 }
 
-// RenderTemplate executes the provided template and returns the error
-// if the execution fails.
+// newTemplate returns an empty template with the helpers of the renderer
+// and its own component helper
+func (r *LayoutRenderer) newTemplate() *template.Template {
+	tmpl := template.New(r.LayoutName).Funcs(r.Helpers)
+	return tmpl.Funcs(template.FuncMap{"component": componentHelper(tmpl)})
+}
+
+// RenderTemplate executes the layout of the provided template and returns
+// the error if the execution fails. The page is rendered into a buffer
+// first, so nothing is written to w if it fails. If w is a Noncer, its
+// nonce is added to the data under "nonce", see withNonce.
 func (r *LayoutRenderer) RenderTemplate(w io.Writer, tmpl *template.Template, data interface{}) error {
-	return errors.New("not implemented")
+	return r.render(w, tmpl, r.LayoutName, data)
+}
+
+// RenderError answers with the status and the ErrorTemplate rendered with
+// the data plus the status and its statusText, or with the statusText if
+// there is no ErrorTemplate or it fails
+func (r *LayoutRenderer) RenderError(w http.ResponseWriter, status int, data map[string]interface{}) {
+	if r.ErrorTemplate == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	page := map[string]interface{}{
+		"status":     status,
+		"statusText": http.StatusText(status),
+	}
+	for k, v := range data {
+		page[k] = v
+	}
+	b, err := r.execute(w, r.ErrorTemplate, r.ErrorTemplate.Name(), page)
+	if err != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer release(b)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	b.WriteTo(w)
+}
+
+// render executes the named template of tmpl and writes it to w at once
+func (r *LayoutRenderer) render(w io.Writer, tmpl *template.Template, name string, data interface{}) error {
+	b, err := r.execute(w, tmpl, name, data)
+	if err != nil {
+		return err
+	}
+	defer release(b)
+
+	_, err = b.WriteTo(w)
+	return err
+}
+
+// execute executes the named template of tmpl into a pooled buffer, with
+// the nonce of w if it is a Noncer. The buffer is released on failure.
+func (r *LayoutRenderer) execute(w io.Writer, tmpl *template.Template, name string, data interface{}) (*bytes.Buffer, error) {
+	b := buffers.Get().(*bytes.Buffer)
+	b.Reset()
+	if err := tmpl.ExecuteTemplate(b, name, withNonce(w, data)); err != nil {
+		release(b)
+		return nil, err
+	}
+	return b, nil
+}
+
+// withNonce returns a copy of map data, or of no data, with the nonce of
+// w under "nonce" if w is a Noncer. Other data is returned as is. The
+// templates are shared by all requests, so the nonce travels with the
+// data rather than with a helper.
+func withNonce(w io.Writer, data interface{}) interface{} {
+	n, ok := w.(Noncer)
+	if !ok {
+		return data
+	}
+	m, ok := data.(map[string]interface{})
+	if !ok && data != nil {
+		return data
+	}
+
+	page := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		page[k] = v
+	}
+	page["nonce"] = n.Nonce()
+	return page
+}
+
+func release(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledBuffer {
+		buffers.Put(b)
+	}
 }
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/PuerkitoBio/goquery"
 	. "github.com/wwgberlin/go-weather-widget/tpl"
 )
 
@@ -109,4 +113,103 @@ func TestRenderTemplate_ErrorHandling(t *testing.T) {
 	if err := rdr.RenderTemplate(&b, tmpl, 1); err == nil {
 		t.Error("RenderTemplate was expected to return an error")
 	}
+	if b.Len() != 0 {
+		t.Errorf("RenderTemplate was expected to write nothing when failing but wrote %q", b.String())
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	rdr := testRenderer(layoutTemplateName, nil)
+	set, err := NewSet(rdr, "./templates", map[string]string{"error.tmpl": layoutTemplateName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdr.ErrorTemplate = set.Template("error.tmpl")
+
+	rr := httptest.NewRecorder()
+	set.RenderError(rr, http.StatusNotFound, map[string]interface{}{
+		"message":   "We couldn't find <Nowhere>.",
+		"requestID": "3f2a9c",
+		"location":  "Nowhere",
+	})
+
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("expected an HTML 404 but got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	doc, err := goquery.NewDocumentFromReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := strings.TrimSpace(doc.Find("title").Text()); title != "Not Found - Weather Forecast" {
+		t.Errorf("unexpected title %q", title)
+	}
+	if message := doc.Find("p.message").Text(); message != "We couldn't find <Nowhere>." {
+		t.Errorf("expected the message in the page but got %q", message)
+	}
+	if v, _ := doc.Find(`form input[name="location"]`).Attr("value"); v != "Nowhere" {
+		t.Errorf("expected the location form with the location but got %q", v)
+	}
+	if id := doc.Find("p.request-id code").Text(); id != "3f2a9c" {
+		t.Errorf("expected the request ID but got %q", id)
+	}
+}
+
+func TestRenderError_Fallback(t *testing.T) {
+	for name, tmpl := range map[string]*template.Template{
+		"no template":      nil,
+		"failing template": template.Must(template.New("error").Parse(`<p>{{.message.Missing}}</p>`)),
+	} {
+		rdr := testRenderer(layoutTemplateName, nil)
+		rdr.ErrorTemplate = tmpl
+
+		rr := httptest.NewRecorder()
+		rdr.RenderError(rr, http.StatusBadGateway, map[string]interface{}{"message": "some error"})
+
+		if rr.Code != http.StatusBadGateway || rr.Body.String() != "Bad Gateway\n" || rr.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+			t.Errorf("%s: expected a plain error but got %d %q", name, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+type nonceBuffer struct {
+	bytes.Buffer
+	nonce string
+}
+
+func (b *nonceBuffer) Nonce() string {
+	return b.nonce
+}
+
+func TestRenderTemplate_Nonce(t *testing.T) {
+	type writer interface {
+		io.Writer
+		String() string
+	}
+	for name, tc := range map[string]struct {
+		w        writer
+		data     interface{}
+		expected string
+	}{
+		"noncer":    {&nonceBuffer{nonce: "abc"}, map[string]interface{}{"location": "Berlin"}, `<style nonce="abc"></style>Berlin`},
+		"no data":   {&nonceBuffer{nonce: "def"}, nil, `<style nonce="def"></style>`},
+		"no noncer": {&bytes.Buffer{}, map[string]interface{}{"location": "Berlin"}, `<style nonce=""></style>Berlin`},
+	} {
+		rdr := testRenderer("page", nil)
+		tmpl := template.Must(template.New("page").Funcs(rdr.Helpers).Parse(
+			`<style nonce="{{with $.nonce}}{{.}}{{end}}"></style>{{with $.location}}{{.}}{{end}}`))
+
+		if err := rdr.RenderTemplate(tc.w, tmpl, tc.data); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if s := tc.w.String(); s != tc.expected {
+			t.Errorf("%s: expected %s but got %s", name, tc.expected, s)
+		}
+	}
+
+	data := map[string]interface{}{"location": "Berlin"}
+	rdr := testRenderer("page", nil)
+	rdr.RenderTemplate(&nonceBuffer{nonce: "abc"}, template.Must(template.New("page").Parse(`{{.nonce}}`)), data)
+	if _, ok := data["nonce"]; ok {
+		t.Error("expected the data of the caller to be left alone")
+	}
 }
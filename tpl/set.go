@@ -0,0 +1,197 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LayoutBlocks are the blocks every page rendered with a layout has to
+// define, by layout name. A block the page defines empty counts.
+var LayoutBlocks = map[string][]string{
+	"layout": {"content", "title", "styles"},
+}
+
+// Set holds the templates of the pages, built once at startup. The
+// layouts and partials are parsed once, then cloned for every theme and
+// page, and every template is rendered with the layout of its page.
+type Set struct {
+	renderer *LayoutRenderer
+	// pages maps the pages to their templates without a theme, themed
+	// to their templates by theme
+	pages  map[string]*template.Template
+	themed map[string]map[string]*template.Template
+	files  map[string][]string
+}
+
+// MissingBlocksError lists the blocks the pages miss, by page
+type MissingBlocksError map[string][]string
+
+func (e MissingBlocksError) Error() string {
+	pages := make([]string, 0, len(e))
+	for page := range e {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+
+	lines := []string{"pages miss blocks of their layouts:"}
+	for _, page := range pages {
+		lines = append(lines, fmt.Sprintf("\t%s: %s", page, strings.Join(e[page], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewSet builds the templates of the pages in dir, mapped to the names of
+// the layouts they are rendered with, for the themes of the renderer. It
+// checks that every page defines its layout and the LayoutBlocks, and
+// returns a MissingBlocksError listing those that don't.
+func NewSet(r *LayoutRenderer, dir string, pages map[string]string) (*Set, error) {
+	shared := sharedFiles(dir)
+	base, err := r.newTemplate().ParseFiles(shared...)
+	if err != nil {
+		return nil, err
+	}
+
+	themed := map[string]*template.Template{}
+	for name, theme := range r.Themes {
+		tmpl, err := clone(base)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.Funcs(template.FuncMap{"theme": themeHelper(theme)})
+		if theme.Layout != "" {
+			if _, err := tmpl.ParseFiles(theme.Layout); err != nil {
+				return nil, err
+			}
+		}
+		themed[name] = tmpl
+	}
+	if _, ok := themed[r.DefaultTheme]; ok {
+		themed[""] = themed[r.DefaultTheme]
+	} else {
+		themed[""] = base
+	}
+
+	s := &Set{
+		renderer: r,
+		pages:    map[string]*template.Template{},
+		themed:   map[string]map[string]*template.Template{},
+		files:    map[string][]string{},
+	}
+	missing := MissingBlocksError{}
+	for page, layout := range pages {
+		file := filepath.Join(dir, page)
+		blocks, err := definedBlocks(r, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.pages[page], err = pageTemplate(base, file, layout); err != nil {
+			return nil, err
+		}
+		s.themed[page] = map[string]*template.Template{}
+		for name, t := range themed {
+			if s.themed[page][name], err = pageTemplate(t, file, layout); err != nil {
+				return nil, err
+			}
+		}
+
+		if s.pages[page] == nil {
+			missing[page] = append(missing[page], layout)
+		}
+		for _, block := range LayoutBlocks[layout] {
+			if !blocks[block] {
+				missing[page] = append(missing[page], block)
+			}
+		}
+
+		s.files[page] = append([]string{file}, shared...)
+		for _, theme := range r.Themes {
+			if theme.Layout != "" {
+				s.files[page] = append(s.files[page], theme.Layout)
+			}
+		}
+		sort.Strings(s.files[page][1+len(shared):])
+	}
+
+	if len(missing) > 0 {
+		return nil, missing
+	}
+	return s, nil
+}
+
+// Template returns the template of the page without a theme. It panics
+// if the page isn't part of the set.
+func (s *Set) Template(page string) *template.Template {
+	tmpl, ok := s.pages[page]
+	if !ok {
+		panic(fmt.Sprintf("tpl: page %s is not part of the set", page))
+	}
+	return tmpl
+}
+
+// ThemeTemplates returns the templates of the page by theme, the default
+// theme also keyed by "". It panics if the page isn't part of the set.
+func (s *Set) ThemeTemplates(page string) map[string]*template.Template {
+	tmpls, ok := s.themed[page]
+	if !ok {
+		panic(fmt.Sprintf("tpl: page %s is not part of the set", page))
+	}
+	return tmpls
+}
+
+// Files returns the files the templates of the page are built from
+func (s *Set) Files(page string) []string {
+	return s.files[page]
+}
+
+// RenderTemplate executes the template of a page with the layout of the
+// page like LayoutRenderer.RenderTemplate
+func (s *Set) RenderTemplate(w io.Writer, tmpl *template.Template, data interface{}) error {
+	return s.renderer.render(w, tmpl, tmpl.Name(), data)
+}
+
+// RenderError answers with the ErrorTemplate of the renderer like
+// LayoutRenderer.RenderError
+func (s *Set) RenderError(w http.ResponseWriter, status int, data map[string]interface{}) {
+	s.renderer.RenderError(w, status, data)
+}
+
+// pageTemplate parses the page into a clone of the template and returns
+// the layout of the page, nil if it isn't defined
+func pageTemplate(t *template.Template, file, layout string) (*template.Template, error) {
+	tmpl, err := clone(t)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.ParseFiles(file); err != nil {
+		return nil, err
+	}
+	return tmpl.Lookup(layout), nil
+}
+
+// clone clones the template and binds its own component helper
+func clone(t *template.Template) (*template.Template, error) {
+	c, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return c.Funcs(template.FuncMap{"component": componentHelper(c)}), nil
+}
+
+// definedBlocks returns the names of the templates the file defines
+func definedBlocks(r *LayoutRenderer, file string) (map[string]bool, error) {
+	tmpl, err := r.newTemplate().ParseFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	blocks := map[string]bool{}
+	for _, t := range tmpl.Templates() {
+		blocks[t.Name()] = true
+	}
+	return blocks, nil
+}
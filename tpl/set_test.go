@@ -0,0 +1,124 @@
+package tpl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+var testPages = map[string]string{
+	"index.tmpl":  layoutTemplateName,
+	"widget.tmpl": layoutTemplateName,
+	"badge.tmpl":  "badge",
+}
+
+func TestNewSet(t *testing.T) {
+	themes, err := LoadThemes("./templates", "../public/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdr := testRenderer(layoutTemplateName, nil)
+	rdr.Themes, rdr.DefaultTheme = themes, "light"
+
+	set, err := NewSet(rdr, "./templates", testPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(page string, data interface{}) *goquery.Document {
+		var b bytes.Buffer
+		if err := set.RenderTemplate(&b, set.Template(page), data); err != nil {
+			t.Fatalf("%s was expected to render without errors. %v", page, err)
+		}
+		doc, err := goquery.NewDocumentFromReader(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	index := render("index.tmpl", map[string]interface{}{"location": "Berlin"})
+	if title := strings.TrimSpace(index.Find("title").Text()); title != "Weather Forecast" {
+		t.Errorf("expected the title of the index but got %q", title)
+	}
+	if index.Find("html").HasClass("theme-light") {
+		t.Error("expected pages without a theme to use the default layout")
+	}
+
+	widget := render("widget.tmpl", map[string]interface{}{"location": "Berlin", "celsius": 12, "description": "Sunny", "condition": weather.Clear, "isDay": true})
+	if title := strings.TrimSpace(widget.Find("title").Text()); title != "Weather Forecast in Berlin" {
+		t.Errorf("expected the title of the widget but got %q", title)
+	}
+	if widget.Find("form").Length() != 0 {
+		t.Error("expected the widget not to share the content of the index")
+	}
+
+	badge := render("badge.tmpl", NewBadge("Berlin", 12, weather.Clear, DefaultBadgeTheme))
+	if badge.Find("svg").Length() != 1 {
+		t.Error("expected the badge to be rendered with its own layout")
+	}
+
+	tmpls := set.ThemeTemplates("widget.tmpl")
+	for _, name := range []string{"", "light", "dark", "minimal", "high-contrast"} {
+		if tmpls[name] == nil {
+			t.Errorf("expected a widget template for theme %q", name)
+		}
+	}
+	var b bytes.Buffer
+	if err := set.RenderTemplate(&b, tmpls["minimal"], map[string]interface{}{"location": "Berlin", "celsius": 12, "description": "Sunny", "condition": weather.Clear, "isDay": true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<html class="theme-minimal">`) || !strings.Contains(b.String(), "<main>") {
+		t.Errorf("expected the layout of the minimal theme but got\n%s", b.String())
+	}
+
+	files := set.Files("widget.tmpl")
+	if files[0] != "templates/widget.tmpl" || !contains(files, "templates/themes/minimal/layout.tmpl") {
+		t.Errorf("expected the files of the page and the theme layouts but got %v", files)
+	}
+}
+
+func TestNewSet_MissingBlocks(t *testing.T) {
+	_, err := NewSet(testRenderer(layoutTemplateName, nil), "./templates", map[string]string{
+		"index.tmpl":              layoutTemplateName,
+		"../test/incomplete.tmpl": layoutTemplateName,
+		"widget.tmpl":             "sidebar",
+	})
+
+	missing, ok := err.(MissingBlocksError)
+	if !ok {
+		t.Fatalf("expected a MissingBlocksError but got %v", err)
+	}
+	expected := "pages miss blocks of their layouts:\n" +
+		"\t../test/incomplete.tmpl: title, styles\n" +
+		"\twidget.tmpl: sidebar"
+	if missing.Error() != expected {
+		t.Errorf("expected the report\n%s\nbut got\n%s", expected, missing.Error())
+	}
+}
+
+func TestSet_UnknownPage(t *testing.T) {
+	set, err := NewSet(testRenderer(layoutTemplateName, nil), "./templates", testPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected pages not in the set to panic")
+		}
+	}()
+	set.Template("admin.tmpl")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
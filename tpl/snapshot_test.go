@@ -0,0 +1,276 @@
+package tpl_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+	"golang.org/x/net/html"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the snapshot tests")
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+	unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// widgetSet builds the widget page like the server does, for the themes
+func widgetSet(t *testing.T, themes Themes) *Set {
+	rdr := testRenderer(layoutTemplateName, nil)
+	rdr.Themes = themes
+	set, err := NewSet(rdr, "./templates", map[string]string{"widget.tmpl": layoutTemplateName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+// TestWidgetSnapshots renders widget.tmpl through the layout for a matrix
+// of conditions and compares the normalized HTML with the golden files in
+// testdata/golden. Run `go test ./tpl -update` to accept a change.
+func TestWidgetSnapshots(t *testing.T) {
+	set := widgetSet(t, nil)
+	tmpl := set.Template("widget.tmpl")
+
+	for _, celsius := range []int{-5, 12, 16, 19, 21, 28} {
+		for description, condition := range map[string]weather.Condition{"Sunny": weather.Clear, "Light rain": weather.Rain} {
+			var b bytes.Buffer
+			if err := set.RenderTemplate(&b, tmpl, map[string]interface{}{
+				"location":    "Berlin",
+				"celsius":     celsius,
+				"description": description,
+				"condition":   condition,
+				"isDay":       true,
+			}); err != nil {
+				t.Fatalf("widget was expected to render without errors. %v", err)
+			}
+
+			temperature := strings.Replace(fmt.Sprint(celsius), "-", "minus", 1)
+			checkSnapshot(t, fmt.Sprintf("widget %s %s", temperature, description), b.String())
+		}
+	}
+}
+
+// TestWidgetSnapshots_Themes renders the widget in every theme
+func TestWidgetSnapshots_Themes(t *testing.T) {
+	themes, err := LoadThemes("./templates", "../public/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := widgetSet(t, themes)
+	tmpls := set.ThemeTemplates("widget.tmpl")
+
+	for name := range themes {
+		var b bytes.Buffer
+		if err := set.RenderTemplate(&b, tmpls[name], map[string]interface{}{
+			"location":    "Berlin",
+			"celsius":     12,
+			"description": "Light rain",
+			"condition":   weather.Rain,
+			"isDay":       true,
+		}); err != nil {
+			t.Fatalf("%s: widget was expected to render without errors. %v", name, err)
+		}
+		checkSnapshot(t, "widget theme "+name, b.String())
+	}
+}
+
+// TestWidgetSnapshot_Night renders a hot night, which shows the night sky
+// and leaves the sunglasses at home
+func TestWidgetSnapshot_Night(t *testing.T) {
+	set := widgetSet(t, nil)
+	tmpl := set.Template("widget.tmpl")
+
+	var b bytes.Buffer
+	if err := set.RenderTemplate(&b, tmpl, map[string]interface{}{
+		"location":    "Berlin",
+		"celsius":     28,
+		"description": "Clear",
+		"condition":   weather.Clear,
+		"isDay":       false,
+	}); err != nil {
+		t.Fatalf("widget was expected to render without errors. %v", err)
+	}
+
+	if strings.Contains(b.String(), "sunglasses") || !strings.Contains(b.String(), `class="sky night"`) {
+		t.Errorf("expected the night sky without sunglasses but got %s", b.String())
+	}
+	checkSnapshot(t, "widget night", b.String())
+}
+
+// TestWidgetSnapshot_Alerts renders a banner per alert, escaping their
+// texts, which come from providers
+func TestWidgetSnapshot_Alerts(t *testing.T) {
+	set := widgetSet(t, nil)
+	tmpl := set.Template("widget.tmpl")
+
+	var b bytes.Buffer
+	if err := set.RenderTemplate(&b, tmpl, map[string]interface{}{
+		"location":    "Hamburg",
+		"celsius":     6,
+		"description": "Heavy rain",
+		"condition":   weather.Rain,
+		"isDay":       true,
+		"alerts": []weather.Alert{
+			{Type: weather.WindAlert, Severity: weather.Severe, Text: "Storm winds of 95 km/h"},
+			{Type: "flood", Severity: weather.Minor, Text: "<b>Rising</b> rivers"},
+		},
+	}); err != nil {
+		t.Fatalf("widget was expected to render without errors. %v", err)
+	}
+
+	if strings.Contains(b.String(), "<b>") {
+		t.Errorf("expected the alert text to be escaped but got %s", b.String())
+	}
+	checkSnapshot(t, "widget alerts", b.String())
+}
+
+// TestWidgetSnapshot_HostileLocation makes sure that the location, which
+// users control, is escaped in the title, the text and the search link
+func TestWidgetSnapshot_HostileLocation(t *testing.T) {
+	set := widgetSet(t, nil)
+	tmpl := set.Template("widget.tmpl")
+
+	var b bytes.Buffer
+	if err := set.RenderTemplate(&b, tmpl, map[string]interface{}{
+		"location":    `"><script>alert(1)</script>&x=`,
+		"celsius":     12,
+		"description": "Sunny",
+		"condition":   weather.Clear,
+		"isDay":       true,
+	}); err != nil {
+		t.Fatalf("widget was expected to render without errors. %v", err)
+	}
+
+	if strings.Contains(b.String(), "<script>") {
+		t.Errorf("expected the location to be escaped but got %s", b.String())
+	}
+	checkSnapshot(t, "widget hostile location", b.String())
+}
+
+// checkSnapshot compares the rendered HTML with the golden file named
+// after the snapshot, or rewrites the golden file with -update
+func checkSnapshot(t *testing.T, name, rendered string) {
+	name = unsafeName.ReplaceAllString(strings.ToLower(name), "_") + ".html"
+	path := filepath.Join("testdata", "golden", name)
+
+	got, err := normalizeHTML(rendered)
+	if err != nil {
+		t.Fatalf("%s: failed to parse rendered HTML. %v", name, err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: missing golden file, run the tests with -update. %v", name, err)
+	}
+	if diff := diffLines(string(want), got); diff != "" {
+		t.Errorf("%s: rendered widget differs from the golden file (run with -update to accept):\n%s", name, diff)
+	}
+}
+
+// normalizeHTML re-renders the document one node per line with sorted
+// attributes and collapsed whitespace, so that snapshots only change
+// when the output does
+func normalizeHTML(s string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	writeNode(&b, doc, 0)
+	return b.String(), nil
+}
+
+func writeNode(w io.Writer, n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch n.Type {
+	case html.DoctypeNode:
+		fmt.Fprintf(w, "<!DOCTYPE %s>\n", n.Data)
+		return
+	case html.TextNode:
+		if txt := strings.TrimSpace(whitespace.ReplaceAllString(n.Data, " ")); txt != "" {
+			fmt.Fprintf(w, "%s%s\n", indent, html.EscapeString(txt))
+		}
+		return
+	case html.CommentNode:
+		return
+	case html.ElementNode:
+		attrs := make([]html.Attribute, len(n.Attr))
+		copy(attrs, n.Attr)
+		sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+
+		fmt.Fprintf(w, "%s<%s", indent, n.Data)
+		for _, a := range attrs {
+			fmt.Fprintf(w, " %s=\"%s\"", a.Key, html.EscapeString(a.Val))
+		}
+		fmt.Fprint(w, ">\n")
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if n.Type == html.DocumentNode {
+			writeNode(w, c, depth)
+		} else {
+			writeNode(w, c, depth+1)
+		}
+	}
+
+	if n.Type == html.ElementNode && !voidElements[n.Data] {
+		fmt.Fprintf(w, "%s</%s>\n", indent, n.Data)
+	}
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// diffLines returns the lines around the first difference of want and
+// got, or an empty string if they are equal
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	i := 0
+	for i < len(wl) && i < len(gl) && wl[i] == gl[i] {
+		i++
+	}
+
+	var b bytes.Buffer
+	for j := i - 2; j < i+3; j++ {
+		if j < 0 {
+			continue
+		}
+		if j < len(wl) {
+			fmt.Fprintf(&b, "line %d want: %s\n", j+1, wl[j])
+		}
+		if j < len(gl) {
+			fmt.Fprintf(&b, "line %d got:  %s\n", j+1, gl[j])
+		}
+	}
+	return b.String()
+}
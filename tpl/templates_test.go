@@ -10,6 +10,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
 )
 
 const layoutTemplateName = "layout"
@@ -202,6 +203,7 @@ func TestTemplateWidget(t *testing.T) {
 	if err = tmpl.ExecuteTemplate(&b, "content", map[string]interface{}{
 		"location":    "Berlin",
 		"description": "It's spring time",
+		"condition":   weather.PartlyCloudy,
 		"celsius":     25,
 	}); err != nil {
 		t.Fatalf("Template was expected to execute without errors. %v", err)
@@ -224,12 +226,12 @@ func TestTemplateWidget(t *testing.T) {
 func myClothes(ret ...string) func(args ...interface{}) ([]string, error) {
 	return func(args ...interface{}) ([]string, error) {
 		if len(args) < 2 {
-			return nil, errors.New("clothe expects 2 arguments to be passed (description, celsius)")
+			return nil, errors.New("clothe expects 2 arguments to be passed (condition, celsius)")
 		}
-		if desc, ok := args[0].(string); !ok {
-			return nil, errors.New("first argument in clothes was expected to be a string (description)")
-		} else if desc != "It's spring time" {
-			return nil, errors.New("first argument in clothes was expected to be the weather description")
+		if condition, ok := args[0].(weather.Condition); !ok {
+			return nil, errors.New("first argument in clothes was expected to be a weather.Condition")
+		} else if condition != weather.PartlyCloudy {
+			return nil, errors.New("first argument in clothes was expected to be the weather condition")
 		}
 		if celsius, ok := args[1].(int); !ok {
 			return nil, errors.New("second argument in clothes was expected to be an integer (celsius)")
@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Theme is a named look of the widget. Its files are found by name:
+//
+//	<templates>/themes/<name>/layout.tmpl     overrides the layout
+//	<static>/themes/<name>/theme.css          is linked after the page styles
+//	<static>/themes/<name>/images/<pack>/     holds alternate character artwork
+//
+// All of them are optional. The layout override links the stylesheet by
+// defining the "theme-styles" block of the head with the theme helper.
+// Alternate artwork replaces the images of the character pack of the same
+// name, file by file.
+type Theme struct {
+	Name string
+	// Layout is the file of the layout override
+	Layout string
+	// Stylesheet is the asset name of the stylesheet
+	Stylesheet string
+	// Images is the asset name of the directory of alternate artwork
+	Images string
+}
+
+// Themes maps the names of the themes to them
+type Themes map[string]*Theme
+
+// LoadThemes finds the themes below the themes directories of the
+// templates and the static files
+func LoadThemes(templatesDir, staticDir string) (Themes, error) {
+	dirs, err := ioutil.ReadDir(filepath.Join(templatesDir, "themes"))
+	if err != nil {
+		return nil, err
+	}
+
+	themes := Themes{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		t := &Theme{Name: dir.Name()}
+		if p := filepath.Join(templatesDir, "themes", t.Name, "layout.tmpl"); exists(p) {
+			t.Layout = p
+		}
+		if name := path.Join("themes", t.Name, "theme.css"); exists(filepath.Join(staticDir, name)) {
+			t.Stylesheet = name
+		}
+		if name := path.Join("themes", t.Name, "images"); exists(filepath.Join(staticDir, name)) {
+			t.Images = name
+		}
+		themes[t.Name] = t
+	}
+	return themes, nil
+}
+
+func themeHelper(t *Theme) func() *Theme {
+	return func() *Theme { return t }
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package tpl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/wwgberlin/go-weather-widget/tpl"
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestLoadThemes(t *testing.T) {
+	themes, err := LoadThemes("./templates", "../public/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"light", "dark", "minimal", "high-contrast"} {
+		theme, ok := themes[name]
+		if !ok {
+			t.Errorf("expected theme %s", name)
+			continue
+		}
+		if theme.Layout != "templates/themes/"+name+"/layout.tmpl" {
+			t.Errorf("%s: unexpected layout %q", name, theme.Layout)
+		}
+		if theme.Stylesheet != "themes/"+name+"/theme.css" {
+			t.Errorf("%s: unexpected stylesheet %q", name, theme.Stylesheet)
+		}
+	}
+	if themes["high-contrast"].Images != "themes/high-contrast/images" || themes["dark"].Images != "" {
+		t.Errorf("expected only high-contrast to bring its own artwork")
+	}
+
+	if _, err := LoadThemes("./missing", "../public/static"); err == nil {
+		t.Error("expected a missing themes directory to fail")
+	}
+}
+
+func TestNewSet_Themes(t *testing.T) {
+	data := map[string]interface{}{"location": "Berlin", "celsius": 12, "description": "Sunny", "condition": weather.Clear, "isDay": true}
+
+	rdr := testRenderer(layoutTemplateName, nil)
+	rdr.Themes = Themes{
+		"dark":  {Name: "dark", Layout: "./templates/themes/dark/layout.tmpl", Stylesheet: "themes/dark/theme.css"},
+		"plain": {Name: "plain"},
+	}
+	rdr.DefaultTheme = "dark"
+
+	set, err := NewSet(rdr, "./templates", map[string]string{"widget.tmpl": layoutTemplateName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpls := set.ThemeTemplates("widget.tmpl")
+	if len(tmpls) != 3 {
+		t.Fatalf("expected a template per theme and the default theme keyed by \"\" but got %v", tmpls)
+	}
+
+	render := func(theme string) string {
+		var b bytes.Buffer
+		if err := set.RenderTemplate(&b, tmpls[theme], data); err != nil {
+			t.Fatalf("%s: %v", theme, err)
+		}
+		return b.String()
+	}
+
+	dark := render("dark")
+	if !strings.Contains(dark, `<html class="theme-dark">`) || !strings.Contains(dark, `href="/themes/dark/theme.css"`) {
+		t.Errorf("expected the dark layout and stylesheet but got %s", dark)
+	}
+	if render("") != dark {
+		t.Error("expected the default theme to be keyed by \"\"")
+	}
+	if plain := render("plain"); !strings.Contains(plain, "<html>") || strings.Contains(plain, "theme.css") {
+		t.Errorf("expected a theme without files to use the default layout but got %s", plain)
+	}
+
+	rdr.DefaultTheme = "missing"
+	set, err = NewSet(rdr, "./templates", map[string]string{"widget.tmpl": layoutTemplateName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := set.RenderTemplate(&b, set.ThemeTemplates("widget.tmpl")[""], data); err != nil || strings.Contains(b.String(), "theme-dark") {
+		t.Errorf("expected an unknown default theme to render without a theme but got %s (%v)", b.String(), err)
+	}
+}
@@ -0,0 +1,213 @@
+package weather
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AlertType is the hazard an alert warns of. Providers may report types
+// beyond those derived from thresholds.
+type AlertType string
+
+// The types of the alerts derived from thresholds
+const (
+	HeatAlert  AlertType = "heat"
+	FrostAlert AlertType = "frost"
+	WindAlert  AlertType = "wind"
+)
+
+// Severity ranks alerts like the Common Alerting Protocol does
+type Severity int
+
+// The severities, from least to most severe
+const (
+	UnknownSeverity Severity = iota
+	Minor
+	Moderate
+	Severe
+	Extreme
+)
+
+var severityNames = []string{
+	UnknownSeverity: "unknown",
+	Minor:           "minor",
+	Moderate:        "moderate",
+	Severe:          "severe",
+	Extreme:         "extreme",
+}
+
+// ParseSeverity returns the severity of the name, in any case, e.g.
+// "Moderate" as providers send it
+func ParseSeverity(name string) (Severity, error) {
+	for s, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return Severity(s), nil
+		}
+	}
+	return UnknownSeverity, fmt.Errorf("unknown severity %q", name)
+}
+
+// String returns the name of the severity, "unknown" for severities out
+// of range
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[UnknownSeverity]
+	}
+	return severityNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Alert warns of severe weather at a location
+type Alert struct {
+	Type     AlertType
+	Severity Severity
+	// Start and End bound the time the alert is in effect, a zero End
+	// means until further notice
+	Start, End time.Time
+	Text       string
+}
+
+// Active reports whether the alert is in effect at the time
+func (a Alert) Active(t time.Time) bool {
+	return !t.Before(a.Start) && (a.End.IsZero() || t.Before(a.End))
+}
+
+// Level is a threshold, the severity of the alerts reaching it and their
+// text, formatted with the value of the conditions
+type Level struct {
+	Value    int
+	Severity Severity
+	Text     string
+}
+
+// Thresholds derive alerts from the conditions, by type. Each lists its
+// levels from the least to the most severe: heat and wind alerts are
+// raised at or above a level, frost alerts at or below.
+type Thresholds struct {
+	HeatCelsius  []Level
+	FrostCelsius []Level
+	WindKmph     []Level
+}
+
+// DefaultThresholds raise heat alerts from 35°C, frost alerts from 0°C
+// and wind alerts from gale force
+var DefaultThresholds = Thresholds{
+	HeatCelsius: []Level{
+		{35, Moderate, "High temperatures of %d°C"},
+		{40, Severe, "Severe heat of %d°C"},
+		{45, Extreme, "Extreme heat of %d°C"},
+	},
+	FrostCelsius: []Level{
+		{0, Minor, "Frost at %d°C"},
+		{-10, Moderate, "Hard frost at %d°C"},
+		{-20, Severe, "Severe frost at %d°C"},
+	},
+	WindKmph: []Level{
+		{62, Moderate, "Gale force winds of %d km/h"},
+		{89, Severe, "Storm winds of %d km/h"},
+		{118, Extreme, "Hurricane force winds of %d km/h"},
+	},
+}
+
+// Alerter is a Forecaster that adds the alerts the thresholds derive from
+// the conditions of another Forecaster to those it reports, and drops the
+// alerts no longer in effect. Alerts of a type the forecaster reports
+// aren't derived again.
+type Alerter struct {
+	forecaster Forecaster
+	thresholds Thresholds
+	now        func() time.Time
+}
+
+// NewAlerter returns an Alerter of f deriving alerts with the thresholds
+func NewAlerter(f Forecaster, t Thresholds) *Alerter {
+	return &Alerter{
+		forecaster: f,
+		thresholds: t,
+		now:        time.Now,
+	}
+}
+
+// Forecast returns the conditions of the forecaster with the alerts in
+// effect, the most severe first
+func (a *Alerter) Forecast(location string) (*Conditions, error) {
+	conditions, err := a.forecaster.Forecast(location)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	reported := map[AlertType]bool{}
+	var alerts []Alert
+	for _, alert := range conditions.Alerts {
+		if alert.Active(now) {
+			alerts = append(alerts, alert)
+			reported[alert.Type] = true
+		}
+	}
+
+	for _, derived := range []struct {
+		alertType AlertType
+		levels    []Level
+		value     int
+		reached   func(value, threshold int) bool
+		text      string
+	}{
+		{HeatAlert, a.thresholds.HeatCelsius, conditions.Celsius, atLeast, "Heat of %d°C"},
+		{FrostAlert, a.thresholds.FrostCelsius, conditions.Celsius, atMost, "Frost at %d°C"},
+		{WindAlert, a.thresholds.WindKmph, conditions.WindKmph, atLeast, "Winds of %d km/h"},
+	} {
+		if reported[derived.alertType] {
+			continue
+		}
+		var level *Level
+		for i, l := range derived.levels {
+			if derived.reached(derived.value, l.Value) {
+				level = &derived.levels[i]
+			}
+		}
+		if level == nil {
+			continue
+		}
+		// levels without a text of their own get a plain one
+		text := derived.text
+		if level.Text != "" {
+			text = level.Text
+		}
+		alerts = append(alerts, Alert{
+			Type:     derived.alertType,
+			Severity: level.Severity,
+			Start:    now,
+			Text:     fmt.Sprintf(text, derived.value),
+		})
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Severity > alerts[j].Severity
+	})
+
+	// a copy, the forecaster may hand out its conditions more than once
+	c := *conditions
+	c.Alerts = alerts
+	return &c, nil
+}
+
+func atLeast(value, threshold int) bool { return value >= threshold }
+
+func atMost(value, threshold int) bool { return value <= threshold }
@@ -0,0 +1,120 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for name, expected := range map[string]Severity{
+		"minor": Minor, "Moderate": Moderate, "SEVERE": Severe, "extreme": Extreme, "unknown": UnknownSeverity,
+	} {
+		if s, err := ParseSeverity(name); err != nil || s != expected {
+			t.Errorf("%s: expected %s but got %s, %v", name, expected, s, err)
+		}
+	}
+	if _, err := ParseSeverity("dire"); err == nil {
+		t.Error("expected an error for an unknown name")
+	}
+}
+
+func TestAlert_Active(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		alert    Alert
+		expected bool
+	}{
+		"open":     {Alert{Start: now.Add(-time.Hour)}, true},
+		"starting": {Alert{Start: now, End: now.Add(time.Hour)}, true},
+		"upcoming": {Alert{Start: now.Add(time.Minute)}, false},
+		"expired":  {Alert{Start: now.Add(-time.Hour), End: now}, false},
+	} {
+		if tc.alert.Active(now) != tc.expected {
+			t.Errorf("%s: expected active to be %v", name, tc.expected)
+		}
+	}
+}
+
+func TestAlerter_Thresholds(t *testing.T) {
+	for name, tc := range map[string]struct {
+		conditions Conditions
+		expected   []Alert
+	}{
+		"mild":      {Conditions{Celsius: 20, WindKmph: 10}, nil},
+		"heat":      {Conditions{Celsius: 35}, []Alert{{Type: HeatAlert, Severity: Moderate, Text: "High temperatures of 35°C"}}},
+		"more heat": {Conditions{Celsius: 42}, []Alert{{Type: HeatAlert, Severity: Severe, Text: "Severe heat of 42°C"}}},
+		"frost":     {Conditions{Celsius: 0}, []Alert{{Type: FrostAlert, Severity: Minor, Text: "Frost at 0°C"}}},
+		"freezing":  {Conditions{Celsius: -25}, []Alert{{Type: FrostAlert, Severity: Severe, Text: "Severe frost at -25°C"}}},
+		"frosty storm": {Conditions{Celsius: -1, WindKmph: 120}, []Alert{
+			{Type: WindAlert, Severity: Extreme, Text: "Hurricane force winds of 120 km/h"},
+			{Type: FrostAlert, Severity: Minor, Text: "Frost at -1°C"},
+		}},
+	} {
+		now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+		a := NewAlerter(ForecasterFunc(func(string) (*Conditions, error) {
+			c := tc.conditions
+			return &c, nil
+		}), DefaultThresholds)
+		a.now = func() time.Time { return now }
+
+		c, err := a.Forecast("Berlin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Alerts) != len(tc.expected) {
+			t.Errorf("%s: expected %v but got %v", name, tc.expected, c.Alerts)
+			continue
+		}
+		for i, alert := range c.Alerts {
+			expected := tc.expected[i]
+			expected.Start = now
+			if alert != expected {
+				t.Errorf("%s: expected %v but got %v", name, expected, alert)
+			}
+		}
+	}
+}
+
+func TestAlerter_Reported(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	reported := &Conditions{
+		Celsius: 38,
+		Alerts: []Alert{
+			{Type: "flood", Severity: Minor, Start: now.Add(-time.Hour), Text: "Flooding"},
+			{Type: HeatAlert, Severity: Extreme, Start: now.Add(-time.Hour), End: now.Add(time.Hour), Text: "Heat wave"},
+			{Type: WindAlert, Severity: Severe, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour), Text: "Gale"},
+		},
+	}
+	a := NewAlerter(ForecasterFunc(func(string) (*Conditions, error) {
+		return reported, nil
+	}), DefaultThresholds)
+	a.now = func() time.Time { return now }
+
+	c, err := a.Forecast("Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Alerts) != 2 || c.Alerts[0].Text != "Heat wave" || c.Alerts[1].Text != "Flooding" {
+		t.Errorf("expected the heat wave and the flooding, the most severe first, but got %v", c.Alerts)
+	}
+	if len(reported.Alerts) != 3 || reported.Alerts[0].Text != "Flooding" {
+		t.Errorf("expected the conditions of the forecaster to be left alone but got %v", reported.Alerts)
+	}
+}
+
+func TestAlerter_PlainText(t *testing.T) {
+	a := NewAlerter(ForecasterFunc(func(string) (*Conditions, error) {
+		return &Conditions{Celsius: 30, WindKmph: 70}, nil
+	}), Thresholds{
+		HeatCelsius: []Level{{25, Minor, "Warm at %d°C"}, {30, Moderate, ""}},
+		WindKmph:    []Level{{60, Minor, ""}},
+	})
+
+	c, err := a.Forecast("Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Alerts) != 2 || c.Alerts[0].Text != "Heat of 30°C" || c.Alerts[1].Text != "Winds of 70 km/h" {
+		t.Errorf("expected plain texts for the levels without one but got %v", c.Alerts)
+	}
+}
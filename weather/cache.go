@@ -0,0 +1,155 @@
+package weather
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStale is how long expired conditions are kept to be served when the
+// forecaster fails, they are evicted after that
+const maxStale = 24 * time.Hour
+
+// Cache is a Forecaster that remembers the conditions returned by
+// another Forecaster for a fixed time to live
+type Cache struct {
+	forecaster Forecaster
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	swept   time.Time
+}
+
+type cacheEntry struct {
+	location   string
+	conditions *Conditions
+	fetched    time.Time
+}
+
+// CacheEntry describes a location held in a Cache
+type CacheEntry struct {
+	Location   string
+	Conditions *Conditions
+	Age        time.Duration
+	ExpiresIn  time.Duration
+}
+
+// NewCache returns a Cache in front of the given forecaster
+func NewCache(f Forecaster, ttl time.Duration) *Cache {
+	return &Cache{
+		forecaster: f,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[string]cacheEntry{},
+	}
+}
+
+// TTL returns the time an entry is kept before it expires
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Forecast returns the cached conditions for the location, asking the
+// underlying forecaster when there are none or they have expired. When
+// the forecaster fails, expired conditions are served rather than none.
+func (c *Cache) Forecast(location string) (*Conditions, error) {
+	c.mu.Lock()
+	e, ok := c.entries[locationKey(location)]
+	c.mu.Unlock()
+
+	if ok && c.now().Sub(e.fetched) < c.ttl {
+		return e.conditions, nil
+	}
+
+	conditions, err := c.Refresh(location)
+	if err != nil && ok {
+		return e.conditions, nil
+	}
+	return conditions, err
+}
+
+// Refresh asks the underlying forecaster for the location regardless of
+// the cached entry and stores the result
+func (c *Cache) Refresh(location string) (*Conditions, error) {
+	conditions, err := c.forecaster.Forecast(location)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	c.entries[locationKey(location)] = cacheEntry{
+		location:   location,
+		conditions: conditions,
+		fetched:    now,
+	}
+	if now.Sub(c.swept) >= c.ttl {
+		c.evict(now)
+	}
+	c.mu.Unlock()
+	return conditions, nil
+}
+
+// evict removes the entries that expired more than maxStale ago. It is
+// called with the lock held, at most once per time to live.
+func (c *Cache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if now.Sub(e.fetched) >= c.ttl+maxStale {
+			delete(c.entries, key)
+		}
+	}
+	c.swept = now
+}
+
+// ExpiresIn returns how long the cached entry for the location stays
+// fresh. ok is false when the location is not cached at all.
+func (c *Cache) ExpiresIn(location string) (d time.Duration, ok bool) {
+	c.mu.Lock()
+	e, ok := c.entries[locationKey(location)]
+	c.mu.Unlock()
+
+	if !ok {
+		return 0, false
+	}
+	return e.fetched.Add(c.ttl).Sub(c.now()), true
+}
+
+// Purge removes the location from the cache
+func (c *Cache) Purge(location string) {
+	c.mu.Lock()
+	delete(c.entries, locationKey(location))
+	c.mu.Unlock()
+}
+
+// Entries returns the cached locations sorted by name, with their ages
+// rounded to the second
+func (c *Cache) Entries() []CacheEntry {
+	now := c.now()
+
+	c.mu.Lock()
+	entries := make([]CacheEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		age := now.Sub(e.fetched)
+		entries = append(entries, CacheEntry{
+			Location:   e.location,
+			Conditions: e.conditions,
+			Age:        age.Round(time.Second),
+			ExpiresIn:  (c.ttl - age).Round(time.Second),
+		})
+	}
+	c.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return locationKey(entries[i].Location) < locationKey(entries[j].Location)
+	})
+	return entries
+}
+
+// locationKey normalizes the location so that "Berlin" and " berlin"
+// share the same entry
+func locationKey(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
@@ -0,0 +1,125 @@
+package weather
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache_Forecast(t *testing.T) {
+	calls := 0
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		calls++
+		return &Conditions{Location: s}, nil
+	}), time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Forecast("Berlin")
+	cache.Forecast(" berlin")
+	if calls != 1 {
+		t.Errorf("expected 1 upstream call but got %d", calls)
+	}
+
+	now = now.Add(time.Minute)
+	if c, _ := cache.Forecast("Berlin"); c == nil || c.Location != "Berlin" {
+		t.Errorf("unexpected result from cache %v", c)
+	}
+	if calls != 2 {
+		t.Errorf("expected expired entry to be refreshed but got %d calls", calls)
+	}
+}
+
+func TestCache_ForecastError(t *testing.T) {
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		return nil, errors.New("some error")
+	}), time.Minute)
+
+	if _, err := cache.Forecast("Berlin"); err == nil {
+		t.Error("expected error from cache")
+	}
+	if _, ok := cache.ExpiresIn("Berlin"); ok {
+		t.Error("failed forecast was not expected to be cached")
+	}
+}
+
+func TestCache_ExpiresIn(t *testing.T) {
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		return &Conditions{}, nil
+	}), time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.Forecast("Berlin")
+
+	now = now.Add(20 * time.Second)
+	if d, ok := cache.ExpiresIn("BERLIN"); !ok || d != 40*time.Second {
+		t.Errorf("expected entry to expire in 40s but got %v (%v)", d, ok)
+	}
+}
+
+func TestCache_EntriesAndPurge(t *testing.T) {
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		return &Conditions{Location: s}, nil
+	}), time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.Forecast("Paris")
+	now = now.Add(10 * time.Second)
+	cache.Forecast("Berlin")
+
+	entries := cache.Entries()
+	if len(entries) != 2 || entries[0].Location != "Berlin" || entries[1].Location != "Paris" {
+		t.Fatalf("expected Berlin and Paris entries but got %v", entries)
+	}
+	if entries[1].Age != 10*time.Second || entries[1].ExpiresIn != 50*time.Second {
+		t.Errorf("unexpected age %v or expiry %v of Paris", entries[1].Age, entries[1].ExpiresIn)
+	}
+
+	cache.Purge("paris")
+	if entries := cache.Entries(); len(entries) != 1 {
+		t.Errorf("expected Paris to be purged but got %v", entries)
+	}
+}
+
+func TestCache_ForecastServesStale(t *testing.T) {
+	failing := false
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		if failing {
+			return nil, errors.New("some error")
+		}
+		return &Conditions{Location: s}, nil
+	}), time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.Forecast("Berlin")
+
+	failing = true
+	now = now.Add(2 * time.Minute)
+
+	if c, err := cache.Forecast("Berlin"); err != nil || c.Location != "Berlin" {
+		t.Errorf("expected stale conditions to be served but got %v, %v", c, err)
+	}
+}
+
+func TestCache_EvictsExpired(t *testing.T) {
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		return &Conditions{Location: s}, nil
+	}), time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.Forecast("Paris")
+	now = now.Add(maxStale)
+	cache.Forecast("Rome")
+	now = now.Add(time.Minute)
+	cache.Forecast("Berlin")
+
+	entries := cache.Entries()
+	if len(entries) != 2 || entries[0].Location != "Berlin" || entries[1].Location != "Rome" {
+		t.Errorf("expected Paris to be evicted but got %v", entries)
+	}
+}
@@ -0,0 +1,86 @@
+package weather
+
+import "fmt"
+
+// Condition is the kind of weather, independent of how a provider words
+// or codes it
+type Condition int
+
+// The conditions, from fair to foul
+const (
+	Unknown Condition = iota
+	Clear
+	PartlyCloudy
+	Cloudy
+	Fog
+	Drizzle
+	Rain
+	Sleet
+	Snow
+	Thunder
+)
+
+var conditionNames = []string{
+	Unknown:      "unknown",
+	Clear:        "clear",
+	PartlyCloudy: "partly-cloudy",
+	Cloudy:       "cloudy",
+	Fog:          "fog",
+	Drizzle:      "drizzle",
+	Rain:         "rain",
+	Sleet:        "sleet",
+	Snow:         "snow",
+	Thunder:      "thunder",
+}
+
+// AllConditions returns all conditions but Unknown
+func AllConditions() []Condition {
+	all := make([]Condition, 0, len(conditionNames)-1)
+	for c := Clear; int(c) < len(conditionNames); c++ {
+		all = append(all, c)
+	}
+	return all
+}
+
+// ParseCondition returns the condition named like String returns it
+func ParseCondition(name string) (Condition, error) {
+	for c, n := range conditionNames {
+		if n == name {
+			return Condition(c), nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown condition %q", name)
+}
+
+// String returns the name of the condition, "unknown" for conditions
+// out of range
+func (c Condition) String() string {
+	if c < 0 || int(c) >= len(conditionNames) {
+		return conditionNames[Unknown]
+	}
+	return conditionNames[c]
+}
+
+// Wet reports whether the condition calls for an umbrella
+func (c Condition) Wet() bool {
+	switch c {
+	case Drizzle, Rain, Sleet, Thunder:
+		return true
+	}
+	return false
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (c Condition) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *Condition) UnmarshalText(text []byte) error {
+	parsed, err := ParseCondition(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
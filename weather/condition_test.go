@@ -0,0 +1,50 @@
+package weather
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	for _, c := range append(AllConditions(), Unknown) {
+		parsed, err := ParseCondition(c.String())
+		if err != nil || parsed != c {
+			t.Errorf("%s: expected to parse back but got %s, %v", c, parsed, err)
+		}
+	}
+	if _, err := ParseCondition("sunny"); err == nil {
+		t.Error("expected an error for an unknown name")
+	}
+}
+
+func TestCondition_String(t *testing.T) {
+	for c, expected := range map[Condition]string{
+		Clear: "clear", PartlyCloudy: "partly-cloudy", Thunder: "thunder", -1: "unknown", 100: "unknown",
+	} {
+		if s := c.String(); s != expected {
+			t.Errorf("%d: expected %s but got %s", c, expected, s)
+		}
+	}
+}
+
+func TestCondition_Wet(t *testing.T) {
+	for _, c := range AllConditions() {
+		expected := c == Drizzle || c == Rain || c == Sleet || c == Thunder
+		if c.Wet() != expected {
+			t.Errorf("%s: expected wet to be %v", c, expected)
+		}
+	}
+}
+
+func TestCondition_JSON(t *testing.T) {
+	var v struct{ Condition Condition }
+	if err := json.Unmarshal([]byte(`{"Condition":"snow"}`), &v); err != nil || v.Condition != Snow {
+		t.Errorf("expected snow but got %s, %v", v.Condition, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Condition":"hail"}`), &v); err == nil {
+		t.Error("expected an error for an unknown condition")
+	}
+	if b, err := json.Marshal(v); err != nil || string(b) != `{"Condition":"snow"}` {
+		t.Errorf("expected the name of the condition but got %s, %v", b, err)
+	}
+}
@@ -0,0 +1,74 @@
+package weather
+
+import (
+	"sync"
+	"time"
+)
+
+// Monitor is a Forecaster that counts the calls made to another
+// Forecaster and remembers its most recent errors
+type Monitor struct {
+	forecaster Forecaster
+	keep       int
+	now        func() time.Time
+
+	mu     sync.Mutex
+	calls  int
+	errors []ForecastError
+}
+
+// ForecastError is an error returned by a monitored Forecaster
+type ForecastError struct {
+	Location string
+	Time     time.Time
+	Err      string
+}
+
+// NewMonitor returns a Monitor of f remembering the last keep errors
+func NewMonitor(f Forecaster, keep int) *Monitor {
+	return &Monitor{
+		forecaster: f,
+		keep:       keep,
+		now:        time.Now,
+	}
+}
+
+// Forecast calls the monitored forecaster and records the outcome
+func (m *Monitor) Forecast(location string) (*Conditions, error) {
+	conditions, err := m.forecaster.Forecast(location)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if err != nil && m.keep > 0 {
+		m.errors = append(m.errors, ForecastError{
+			Location: location,
+			Time:     m.now(),
+			Err:      err.Error(),
+		})
+		if len(m.errors) > m.keep {
+			m.errors = m.errors[len(m.errors)-m.keep:]
+		}
+	}
+	return conditions, err
+}
+
+// Calls returns the number of calls made to the monitored forecaster
+func (m *Monitor) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// Errors returns the remembered errors, most recent first
+func (m *Monitor) Errors() []ForecastError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	errs := make([]ForecastError, len(m.errors))
+	for i, e := range m.errors {
+		errs[len(errs)-1-i] = e
+	}
+	return errs
+}
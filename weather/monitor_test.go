@@ -0,0 +1,31 @@
+package weather
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor(ForecasterFunc(func(s string) (*Conditions, error) {
+		if s == "Berlin" {
+			return &Conditions{}, nil
+		}
+		return nil, errors.New("unknown " + s)
+	}), 2)
+
+	for _, l := range []string{"Berlin", "a", "b", "Berlin", "c"} {
+		m.Forecast(l)
+	}
+
+	if m.Calls() != 5 {
+		t.Errorf("expected 5 calls but got %d", m.Calls())
+	}
+
+	errs := m.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected the last 2 errors but got %v", errs)
+	}
+	if errs[0].Location != "c" || errs[0].Err != "unknown c" || errs[1].Location != "b" {
+		t.Errorf("expected errors for c and b, most recent first, but got %v", errs)
+	}
+}
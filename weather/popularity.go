@@ -0,0 +1,109 @@
+package weather
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultPopularityLimit is the number of locations a new Popularity
+// counts requests of
+const DefaultPopularityLimit = 1000
+
+// Popularity counts how often each location is requested. It counts at
+// most Limit locations: when a new location comes in with all of them
+// taken, the counts decay by half and the locations dropping to zero, or
+// the least requested one, make room.
+type Popularity struct {
+	Limit int
+
+	mu     sync.Mutex
+	counts map[string]*LocationCount
+}
+
+// LocationCount is the number of requests seen for a location
+type LocationCount struct {
+	Location string `json:"location"`
+	Requests int    `json:"requests"`
+}
+
+// NewPopularity returns an empty Popularity
+func NewPopularity() *Popularity {
+	return &Popularity{
+		Limit:  DefaultPopularityLimit,
+		counts: map[string]*LocationCount{},
+	}
+}
+
+// Track counts a request for the location
+func (p *Popularity) Track(location string) {
+	key := locationKey(location)
+	if key == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lc, ok := p.counts[key]
+	if !ok {
+		if p.Limit > 0 && len(p.counts) >= p.Limit {
+			p.decay()
+		}
+		lc = &LocationCount{Location: key}
+		p.counts[key] = lc
+	}
+	lc.Requests++
+}
+
+// decay halves the counts and drops the locations reaching zero, or the
+// least requested one if none does. It is called with the lock held.
+func (p *Popularity) decay() {
+	var least *LocationCount
+	for key, lc := range p.counts {
+		lc.Requests /= 2
+		if lc.Requests == 0 {
+			delete(p.counts, key)
+		} else if least == nil || lc.Requests < least.Requests ||
+			lc.Requests == least.Requests && lc.Location > least.Location {
+			least = lc
+		}
+	}
+	if len(p.counts) >= p.Limit {
+		delete(p.counts, least.Location)
+	}
+}
+
+// Tracking returns a Forecaster that tracks every location f
+// forecasts successfully, so that typos don't become popular
+func (p *Popularity) Tracking(f Forecaster) Forecaster {
+	return ForecasterFunc(func(location string) (*Conditions, error) {
+		conditions, err := f.Forecast(location)
+		if err == nil {
+			p.Track(location)
+		}
+		return conditions, err
+	})
+}
+
+// Top returns the n most requested locations, most requested first.
+// A negative n returns all of them.
+func (p *Popularity) Top(n int) []LocationCount {
+	p.mu.Lock()
+	top := make([]LocationCount, 0, len(p.counts))
+	for _, lc := range p.counts {
+		top = append(top, *lc)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Requests != top[j].Requests {
+			return top[i].Requests > top[j].Requests
+		}
+		return top[i].Location < top[j].Location
+	})
+
+	if n >= 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
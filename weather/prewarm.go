@@ -0,0 +1,86 @@
+package weather
+
+import (
+	"log"
+	"time"
+)
+
+// Prewarmer periodically refreshes the most popular locations in a
+// Cache before their entries expire, so that popular requests never
+// have to wait for the upstream forecaster
+type Prewarmer struct {
+	Cache      *Cache
+	Popularity *Popularity
+
+	// TopN is the number of popular locations kept warm
+	TopN int
+	// CallsPerMinute caps the upstream calls made by the prewarmer
+	CallsPerMinute int
+	// Interval is the time between two checks of the cache
+	Interval time.Duration
+	// Ahead refreshes entries that expire within this window
+	Ahead time.Duration
+
+	now         func() time.Time
+	windowStart time.Time
+	calls       int
+}
+
+// NewPrewarmer returns a Prewarmer keeping the topN locations of p warm
+// in c with at most callsPerMinute upstream calls
+func NewPrewarmer(c *Cache, p *Popularity, topN, callsPerMinute int) *Prewarmer {
+	return &Prewarmer{
+		Cache:          c,
+		Popularity:     p,
+		TopN:           topN,
+		CallsPerMinute: callsPerMinute,
+		Interval:       10 * time.Second,
+		Ahead:          c.TTL() / 5,
+		now:            time.Now,
+	}
+}
+
+// Run checks the cache every Interval until stop is closed
+func (p *Prewarmer) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.Warm()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Warm refreshes the popular locations that are missing from the
+// cache or about to expire, as far as the budget allows
+func (p *Prewarmer) Warm() {
+	for _, lc := range p.Popularity.Top(p.TopN) {
+		if d, ok := p.Cache.ExpiresIn(lc.Location); ok && d > p.Ahead {
+			continue
+		}
+		if !p.spend() {
+			return
+		}
+		if _, err := p.Cache.Refresh(lc.Location); err != nil {
+			log.Printf("prewarm of %q failed: %s", lc.Location, err)
+		}
+	}
+}
+
+// spend takes one call out of the budget of the current minute
+func (p *Prewarmer) spend() bool {
+	now := p.now()
+	if now.Sub(p.windowStart) >= time.Minute {
+		p.windowStart = now
+		p.calls = 0
+	}
+	if p.calls >= p.CallsPerMinute {
+		return false
+	}
+	p.calls++
+	return true
+}
@@ -0,0 +1,103 @@
+package weather
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPopularity_Top(t *testing.T) {
+	p := NewPopularity()
+	for _, l := range []string{"Berlin", "Paris", "berlin", "Rome", "Paris", "BERLIN", ""} {
+		p.Track(l)
+	}
+
+	expected := []LocationCount{
+		{Location: "berlin", Requests: 3},
+		{Location: "paris", Requests: 2},
+	}
+	if top := p.Top(2); !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %v but got %v", expected, top)
+	}
+	if top := p.Top(-1); len(top) != 3 {
+		t.Errorf("expected all 3 locations but got %v", top)
+	}
+}
+
+func TestPopularity_Limit(t *testing.T) {
+	p := NewPopularity()
+	p.Limit = 2
+	for _, l := range []string{"Berlin", "Berlin", "Berlin", "Paris", "Paris", "Rome"} {
+		p.Track(l)
+	}
+	expected := []LocationCount{{Location: "berlin", Requests: 1}, {Location: "rome", Requests: 1}}
+	if top := p.Top(-1); !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected the counts to decay making room for rome %v but got %v", expected, top)
+	}
+
+	p.Track("Oslo")
+	expected = []LocationCount{{Location: "oslo", Requests: 1}}
+	if top := p.Top(-1); !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %v but got %v", expected, top)
+	}
+}
+
+func TestPopularity_Tracking(t *testing.T) {
+	p := NewPopularity()
+	f := p.Tracking(ForecasterFunc(func(s string) (*Conditions, error) {
+		if s == "nowhere" {
+			return nil, errors.New("unknown location")
+		}
+		return &Conditions{}, nil
+	}))
+
+	f.Forecast("Berlin")
+	f.Forecast("nowhere")
+
+	expected := []LocationCount{{Location: "berlin", Requests: 1}}
+	if top := p.Top(-1); !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %v but got %v", expected, top)
+	}
+}
+
+func TestPrewarmer_Warm(t *testing.T) {
+	var refreshed []string
+	cache := NewCache(ForecasterFunc(func(s string) (*Conditions, error) {
+		refreshed = append(refreshed, s)
+		return &Conditions{}, nil
+	}), 10*time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	p := NewPopularity()
+	for _, l := range []string{"a", "a", "a", "b", "b", "c"} {
+		p.Track(l)
+	}
+	cache.Forecast("b")
+	refreshed = nil
+
+	w := NewPrewarmer(cache, p, 3, 1)
+	w.now = cache.now
+
+	w.Warm()
+	if !reflect.DeepEqual(refreshed, []string{"a"}) {
+		t.Errorf("expected only a to be warmed within budget but got %v", refreshed)
+	}
+
+	now = now.Add(time.Minute)
+	refreshed = nil
+	w.CallsPerMinute = 5
+	w.Warm()
+	if !reflect.DeepEqual(refreshed, []string{"c"}) {
+		t.Errorf("expected only c to be warmed but got %v", refreshed)
+	}
+
+	now = now.Add(7*time.Minute + 30*time.Second)
+	refreshed = nil
+	w.Warm()
+	if !reflect.DeepEqual(refreshed, []string{"a", "b"}) {
+		t.Errorf("expected a and b to be warmed ahead of expiry but got %v", refreshed)
+	}
+}
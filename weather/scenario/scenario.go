@@ -0,0 +1,134 @@
+// Package scenario provides a weather.Forecaster answering from a
+// scenario file instead of calling a weather API, so that every look of
+// the widget can be reproduced for demos and visual testing.
+//
+// A scenario file maps locations to one or more conditions. A location
+// with several conditions cycles through them as a timeline, showing
+// each for the given interval. The location "*" answers for every
+// location not listed. Conditions are named like weather.Condition
+// names them, conditions after sunset are marked "night". Conditions may
+// have a wind speed and alerts:
+//
+//	{"celsius": 6, "condition": "rain", "wind_kmph": 95,
+//		"alerts": [{"type": "wind", "severity": "severe", "text": "Storm warning"}]}
+//
+//	{
+//		"interval": "10s",
+//		"locations": {
+//			"berlin": [{"celsius": 9, "description": "Light rain", "condition": "rain"}],
+//			"timeline": [
+//				{"celsius": 30, "description": "Sunny", "condition": "clear"},
+//				{"celsius": -2, "description": "Light sleet", "condition": "sleet", "night": true}
+//			]
+//		}
+//	}
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+// Forecaster answers with the conditions of a scenario
+type Forecaster struct {
+	interval  time.Duration
+	locations map[string][]weather.Conditions
+	start     time.Time
+	now       func() time.Time
+}
+
+type file struct {
+	Interval  string                  `json:"interval"`
+	Locations map[string][]conditions `json:"locations"`
+}
+
+type conditions struct {
+	Location    string            `json:"location"`
+	Celsius     int               `json:"celsius"`
+	Description string            `json:"description"`
+	Condition   weather.Condition `json:"condition"`
+	Night       bool              `json:"night"`
+	WindKmph    int               `json:"wind_kmph"`
+	Alerts      []weather.Alert   `json:"alerts"`
+}
+
+// Load reads the scenario file at path
+func Load(path string) (*Forecaster, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %s", path, err)
+	}
+
+	fc := &Forecaster{
+		interval:  10 * time.Second,
+		locations: make(map[string][]weather.Conditions, len(f.Locations)),
+		now:       time.Now,
+	}
+	fc.start = fc.now()
+
+	if f.Interval != "" {
+		if fc.interval, err = time.ParseDuration(f.Interval); err != nil || fc.interval <= 0 {
+			return nil, fmt.Errorf("invalid interval %q in scenario file %s", f.Interval, path)
+		}
+	}
+
+	for l, timeline := range f.Locations {
+		if len(timeline) == 0 {
+			return nil, fmt.Errorf("location %s has no conditions in scenario file %s", l, path)
+		}
+		key := normalize(l)
+		for _, c := range timeline {
+			location := c.Location
+			if location == "" {
+				location = l
+			}
+			fc.locations[key] = append(fc.locations[key], weather.Conditions{
+				Location:    location,
+				Celsius:     c.Celsius,
+				Description: c.Description,
+				Condition:   c.Condition,
+				IsDay:       !c.Night,
+				WindKmph:    c.WindKmph,
+				Alerts:      c.Alerts,
+			})
+		}
+	}
+	return fc, nil
+}
+
+// Interval returns the time each step of a timeline is shown
+func (f *Forecaster) Interval() time.Duration {
+	return f.interval
+}
+
+// Forecast returns the conditions the scenario defines for the location
+// at this point of its timeline
+func (f *Forecaster) Forecast(location string) (*weather.Conditions, error) {
+	timeline, ok := f.locations[normalize(location)]
+	if !ok {
+		if timeline, ok = f.locations["*"]; !ok {
+			return nil, &weather.NotFoundError{Location: location}
+		}
+	}
+
+	step := int(f.now().Sub(f.start)/f.interval) % len(timeline)
+	c := timeline[step]
+	if c.Location == "*" {
+		c.Location = location
+	}
+	return &c, nil
+}
+
+func normalize(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
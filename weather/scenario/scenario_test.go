@@ -0,0 +1,113 @@
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func writeScenario(t *testing.T, content string) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, "scenario.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestForecaster_Forecast(t *testing.T) {
+	path, cleanup := writeScenario(t, `{
+		"locations": {
+			"Berlin": [{"celsius": 9, "description": "Light rain", "condition": "rain", "wind_kmph": 70,
+				"alerts": [{"type": "wind", "severity": "moderate", "text": "Gale"}]}],
+			"*": [{"location": "*", "celsius": 20, "description": "Clear", "night": true}]
+		}
+	}`)
+	defer cleanup()
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for location, expected := range map[string]weather.Conditions{
+		" berlin": {
+			Location: "Berlin", Celsius: 9, Description: "Light rain", Condition: weather.Rain, IsDay: true, WindKmph: 70,
+			Alerts: []weather.Alert{{Type: weather.WindAlert, Severity: weather.Moderate, Text: "Gale"}},
+		},
+		"Paris": {Location: "Paris", Celsius: 20, Description: "Clear"},
+	} {
+		if c, err := f.Forecast(location); err != nil || !reflect.DeepEqual(*c, expected) {
+			t.Errorf("%s: expected %v but got %v, %v", location, expected, c, err)
+		}
+	}
+}
+
+func TestForecaster_Timeline(t *testing.T) {
+	path, cleanup := writeScenario(t, `{
+		"interval": "1m",
+		"locations": {
+			"timeline": [
+				{"celsius": 1, "description": "first"},
+				{"celsius": 2, "description": "second"}
+			]
+		}
+	}`)
+	defer cleanup()
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Interval() != time.Minute {
+		t.Errorf("expected an interval of 1m but got %v", f.Interval())
+	}
+	now := f.start
+	f.now = func() time.Time { return now }
+
+	for _, expected := range []int{1, 1, 2, 1} {
+		if c, _ := f.Forecast("timeline"); c.Celsius != expected {
+			t.Errorf("expected step with %d°C at %v but got %v", expected, now.Sub(f.start), c)
+		}
+		now = now.Add(40 * time.Second)
+	}
+}
+
+func TestForecaster_UnknownLocation(t *testing.T) {
+	path, cleanup := writeScenario(t, `{"locations": {"berlin": [{"celsius": 9}]}}`)
+	defer cleanup()
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Forecast("Paris"); err == nil {
+		t.Error("expected an error for a location without scenario")
+	} else if _, ok := err.(*weather.NotFoundError); !ok {
+		t.Errorf("expected a NotFoundError but got %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid json":      `{"locations": `,
+		"invalid interval":  `{"interval": "often", "locations": {}}`,
+		"empty timeline":    `{"locations": {"berlin": []}}`,
+		"unknown condition": `{"locations": {"berlin": [{"condition": "hail"}]}}`,
+		"unknown severity":  `{"locations": {"berlin": [{"alerts": [{"severity": "dire"}]}]}}`,
+	} {
+		path, cleanup := writeScenario(t, content)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected Load to fail", name)
+		}
+		cleanup()
+	}
+}
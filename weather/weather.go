@@ -1,5 +1,7 @@
 package weather
 
+import "fmt"
+
 // Forecaster can query for the conditions in a given
 // location
 type Forecaster interface {
@@ -14,10 +16,36 @@ func (f ForecasterFunc) Forecast(location string) (forecast *Conditions, err err
 	return f(location)
 }
 
+// NotFoundError is returned by forecasters that know no location matching
+// the query
+type NotFoundError struct {
+	Location string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no location matching %q", e.Location)
+}
+
+// UnavailableError is returned by forecasters whose upstream service
+// fails to answer, e.g. with a server error or by rejecting every key
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
 // Conditions describes a set of info about the
 // weather in a location on a single point in turn
 type Conditions struct {
 	Location    string
 	Celsius     int
 	Description string
+	Condition   Condition
+	WindKmph    int
+	// IsDay is whether the sun is up at the location
+	IsDay bool
+	// Alerts warn of severe weather, see Alerter
+	Alerts []Alert
 }
@@ -0,0 +1,76 @@
+package worldweatheronline
+
+import (
+	"strconv"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+// weatherCodes maps the weather codes of the API to conditions, see
+// https://www.worldweatheronline.com/weather-api/api/docs/weather-icons.aspx
+var weatherCodes = map[int]weather.Condition{
+	113: weather.Clear, // Sunny, Clear
+	116: weather.PartlyCloudy,
+	119: weather.Cloudy,
+	122: weather.Cloudy, // Overcast
+
+	143: weather.Fog, // Mist
+	248: weather.Fog,
+	260: weather.Fog, // Freezing fog
+
+	185: weather.Drizzle, // Patchy freezing drizzle possible
+	263: weather.Drizzle,
+	266: weather.Drizzle,
+	281: weather.Drizzle, // Freezing drizzle
+	284: weather.Drizzle, // Heavy freezing drizzle
+
+	176: weather.Rain, // Patchy rain possible
+	293: weather.Rain,
+	296: weather.Rain,
+	299: weather.Rain,
+	302: weather.Rain,
+	305: weather.Rain,
+	308: weather.Rain,
+	311: weather.Rain, // Light freezing rain
+	314: weather.Rain, // Moderate or heavy freezing rain
+	353: weather.Rain, // Light rain shower
+	356: weather.Rain,
+	359: weather.Rain, // Torrential rain shower
+
+	182: weather.Sleet, // Patchy sleet possible
+	317: weather.Sleet,
+	320: weather.Sleet,
+	350: weather.Sleet, // Ice pellets
+	362: weather.Sleet,
+	365: weather.Sleet,
+	374: weather.Sleet, // Light showers of ice pellets
+	377: weather.Sleet,
+
+	179: weather.Snow, // Patchy snow possible
+	227: weather.Snow, // Blowing snow
+	230: weather.Snow, // Blizzard
+	323: weather.Snow,
+	326: weather.Snow,
+	329: weather.Snow,
+	332: weather.Snow,
+	335: weather.Snow,
+	338: weather.Snow,
+	368: weather.Snow, // Light snow showers
+	371: weather.Snow,
+
+	200: weather.Thunder, // Thundery outbreaks possible
+	386: weather.Thunder, // Patchy light rain with thunder
+	389: weather.Thunder,
+	392: weather.Thunder, // Patchy light snow with thunder
+	395: weather.Thunder,
+}
+
+// Condition returns the condition of a weather code of the API, Unknown
+// for codes it doesn't know
+func Condition(code string) weather.Condition {
+	c, err := strconv.Atoi(code)
+	if err != nil {
+		return weather.Unknown
+	}
+	return weatherCodes[c]
+}
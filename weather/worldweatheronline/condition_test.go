@@ -0,0 +1,35 @@
+package worldweatheronline
+
+import (
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestCondition(t *testing.T) {
+	for code, expected := range map[string]weather.Condition{
+		"113": weather.Clear, "116": weather.PartlyCloudy, "122": weather.Cloudy, "248": weather.Fog,
+		"266": weather.Drizzle, "302": weather.Rain, "317": weather.Sleet, "338": weather.Snow,
+		"389": weather.Thunder, "999": weather.Unknown, "": weather.Unknown, "sunny": weather.Unknown,
+	} {
+		if c := Condition(code); c != expected {
+			t.Errorf("%q: expected %s but got %s", code, expected, c)
+		}
+	}
+}
+
+func TestCondition_Fixtures(t *testing.T) {
+	c := New([]string{"some key"}, WithTransport(&ReplayTransport{Dir: fixturesDir}))
+
+	for location, expected := range map[string]weather.Condition{
+		"Berlin": weather.Rain, "Cairo": weather.Clear, "London": weather.Cloudy,
+	} {
+		conditions, err := c.Forecast(location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conditions.Condition != expected {
+			t.Errorf("%s: expected %s but got %s", location, expected, conditions.Condition)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package worldweatheronline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var unsafeFixtureChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// RecordingTransport passes requests on to Transport and saves every
+// exchange as a fixture file in Dir, with the API key scrubbed
+type RecordingTransport struct {
+	Dir       string
+	Transport http.RoundTripper
+}
+
+// ReplayTransport answers requests from the fixture files in Dir
+// without touching the network
+type ReplayTransport struct {
+	Dir string
+}
+
+// fixture is a recorded exchange with the API
+type fixture struct {
+	URL      string          `json:"url"`
+	Status   int             `json:"status"`
+	Header   http.Header     `json:"header,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	f := fixture{
+		URL:    scrubKey(req.URL).String(),
+		Status: res.StatusCode,
+		Header: http.Header{"Content-Type": res.Header["Content-Type"]},
+	}
+	if json.Valid(body) {
+		f.Body = body
+	} else {
+		f.BodyText = string(body)
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(fixturePath(t.Dir, req.URL), b, 0644); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(t.Dir, req.URL)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s: %s", scrubKey(req.URL), err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %s", path, err)
+	}
+
+	body := []byte(f.BodyText)
+	if len(f.Body) > 0 {
+		body = f.Body
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// fixturePath names the fixture of a request after the queried location
+func fixturePath(dir string, u *url.URL) string {
+	q := strings.ToLower(strings.TrimSpace(u.Query().Get("q")))
+	name := strings.Trim(unsafeFixtureChars.ReplaceAllString(q, "_"), "_")
+	if name == "" {
+		name = "_empty"
+	}
+	return filepath.Join(dir, name+".json")
+}
+
+// scrubKey returns a copy of the URL without the API key
+func scrubKey(u *url.URL) *url.URL {
+	scrubbed := *u
+	params := u.Query()
+	if _, ok := params["key"]; ok {
+		params.Set("key", "REDACTED")
+	}
+	scrubbed.RawQuery = params.Encode()
+	return &scrubbed
+}
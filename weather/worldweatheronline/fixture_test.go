@@ -0,0 +1,75 @@
+package worldweatheronline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+const fixturesDir = "./testdata/fixtures"
+
+func TestReplayTransport(t *testing.T) {
+	c := New([]string{"some key"}, WithTransport(&ReplayTransport{Dir: fixturesDir}))
+
+	conditions, err := c.Forecast(" Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := weather.Conditions{Location: "City Berlin, Germany", Celsius: 9, Description: "Light rain", Condition: weather.Rain, IsDay: true, WindKmph: 19}
+	if !reflect.DeepEqual(*conditions, expected) {
+		t.Errorf("expected %v but got %v", expected, *conditions)
+	}
+}
+
+func TestReplayTransport_Errors(t *testing.T) {
+	c := New([]string{"some key"}, WithTransport(&ReplayTransport{Dir: fixturesDir}))
+
+	if _, err := c.Forecast("Nowhere"); err == nil {
+		t.Error("expected the recorded API error")
+	} else if nf, ok := err.(*weather.NotFoundError); !ok || nf.Location != "Nowhere" {
+		t.Errorf("expected the recorded API error to be a NotFoundError but got %v", err)
+	}
+	if _, err := c.Forecast("Atlantis"); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Errorf("expected a missing fixture error but got %v", err)
+	}
+}
+
+func TestRecordingTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, okResponse)
+	}))
+	defer srv.Close()
+
+	recorder := New([]string{"secret key"}, WithAPIURL(srv.URL), WithTransport(&RecordingTransport{Dir: dir}))
+	if _, err := recorder.Forecast("Berlin"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "berlin.json"))
+	if err != nil {
+		t.Fatalf("expected fixture berlin.json to be recorded: %v", err)
+	}
+	if strings.Contains(string(b), "secret") || !strings.Contains(string(b), "key=REDACTED") {
+		t.Errorf("expected the API key to be scrubbed from the fixture but got %s", b)
+	}
+
+	replayed, err := New([]string{"other key"}, WithTransport(&ReplayTransport{Dir: dir})).Forecast("berlin")
+	if err != nil || replayed.Celsius != 12 || replayed.Description != "Sunny" {
+		t.Errorf("expected the recorded conditions to be replayed but got %v, %v", replayed, err)
+	}
+}
@@ -2,9 +2,14 @@ package worldweatheronline
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/wwgberlin/go-weather-widget/weather"
 )
@@ -12,45 +17,195 @@ import (
 var (
 	apiURL          = "https://api.worldweatheronline.com"
 	weatherEndpoint = "premium/v1/weather.ashx"
+
+	// the messages of the API rejecting a key, or a key out of calls
+	keyErrorMsg      = regexp.MustCompile(`(?i)api key is invalid|api key has reached|problem with your api key|no api key`)
+	notFoundErrorMsg = regexp.MustCompile(`(?i)unable to find any matching`)
 )
 
-// New returns a new forecaster that returns data from World Weather Online
-func New(apiKey string) weather.Forecaster {
-	return weather.ForecasterFunc(getForecast(apiKey))
-}
-
-func getForecast(apiKey string) func(string) (*weather.Conditions, error) {
-	return func(location string) (*weather.Conditions, error) {
-		params := request(location).encodeWithDefaults(apiKey)
-		res, resErr := http.Get(
-			fmt.Sprintf("%s/%s?%s", apiURL, weatherEndpoint, params),
-		)
-		if resErr != nil {
-			return nil, fmt.Errorf("request errored %s", resErr)
-		} else if res.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("request errored with status %v", res.StatusCode)
+// Option configures the Client returned by New
+type Option func(*Client)
+
+// WithQuota accounts every call against the given quota and stops
+// calling the API with a key once its quota is spent
+func WithQuota(q *Quota) Option {
+	return func(c *Client) {
+		c.quota = q
+	}
+}
+
+// WithBenchDuration sets how long a key is left out of the rotation
+// after an auth or quota error
+func WithBenchDuration(d time.Duration) Option {
+	return func(c *Client) {
+		c.keys.benchFor = d
+	}
+}
+
+// WithTransport makes the requests to the API through the given
+// transport, e.g. a RecordingTransport or a ReplayTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.http = &http.Client{Transport: rt, Timeout: c.http.Timeout}
+	}
+}
+
+// WithAPIURL points the client at another server speaking the API,
+// e.g. cmd/fakeweather
+func WithAPIURL(u string) Option {
+	return func(c *Client) {
+		c.apiURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// Client is a forecaster that returns data from World Weather Online,
+// spreading the calls round-robin over a pool of API keys
+type Client struct {
+	keys   *keyPool
+	quota  *Quota
+	http   *http.Client
+	apiURL string
+}
+
+// keyError is an error caused by the API key rather than by the request
+type keyError string
+
+func (e keyError) Error() string {
+	return string(e)
+}
+
+// New returns a new Client using the given API keys
+func New(apiKeys []string, opts ...Option) *Client {
+	c := &Client{
+		keys:   newKeyPool(apiKeys, 15*time.Minute),
+		http:   &http.Client{Timeout: 10 * time.Second},
+		apiURL: apiURL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Forecast returns the current conditions for the given location. Keys
+// failing with auth or quota errors are benched and the next key is
+// tried. When the API fails, or rejects every key, the error is a
+// *weather.UnavailableError.
+func (c *Client) Forecast(location string) (*weather.Conditions, error) {
+	err := ErrNoKeyAvailable
+	exhausted := func(key string) bool {
+		if c.quota != nil && c.quota.Degraded(key) {
+			err = ErrQuotaExceeded
+			return true
 		}
-		defer res.Body.Close()
-		b, bytesErr := ioutil.ReadAll(res.Body)
-		if bytesErr != nil {
-			return nil, bytesErr
+		return false
+	}
+
+	for i := 0; i < len(c.keys.keys); i++ {
+		key, ok := c.keys.take(exhausted)
+		if !ok {
+			break
+		}
+		if c.quota != nil {
+			if err = c.quota.Spend(key); err != nil {
+				continue
+			}
+		}
+
+		var conditions *weather.Conditions
+		if conditions, err = c.get(key, location); err == nil {
+			return conditions, nil
 		}
-		var response response
-		if unmarshalErr := json.Unmarshal(b, &response); unmarshalErr != nil {
-			return nil, unmarshalErr
+
+		_, isKeyErr := err.(keyError)
+		c.keys.failed(key, err, isKeyErr)
+		if !isKeyErr {
+			return nil, err
 		}
+	}
+	if _, ok := err.(keyError); ok {
+		return nil, &weather.UnavailableError{Err: err}
+	}
+	return nil, err
+}
 
-		return buildResponse(&response)
+// Health reports on every API key of the client
+func (c *Client) Health() []KeyHealth {
+	if c.quota == nil {
+		return c.keys.health(nil)
 	}
+	return c.keys.health(func(key string) *KeyUsage {
+		u := c.quota.UsageOf(key)
+		return &u
+	})
 }
 
-func buildResponse(response *response) (*weather.Conditions, error) {
-	if response.Error() != nil {
-		return nil, response.Error()
+func (c *Client) get(apiKey, location string) (*weather.Conditions, error) {
+	params := request(location).encodeWithDefaults(apiKey)
+	res, resErr := c.http.Get(
+		fmt.Sprintf("%s/%s?%s", c.apiURL, weatherEndpoint, params),
+	)
+	if resErr != nil {
+		return nil, &weather.UnavailableError{Err: fmt.Errorf("request errored %s", scrubError(resErr))}
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return nil, keyError(fmt.Sprintf("request errored with status %v", res.StatusCode))
+	default:
+		return nil, &weather.UnavailableError{Err: fmt.Errorf("request errored with status %v", res.StatusCode)}
+	}
+
+	b, bytesErr := ioutil.ReadAll(res.Body)
+	if bytesErr != nil {
+		return nil, bytesErr
+	}
+	var response response
+	if unmarshalErr := json.Unmarshal(b, &response); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return buildResponse(&response, location)
+}
+
+// scrubError removes the API key from the URL of errors of the http
+// client, which end up in logs and in the health report
+func scrubError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return errors.New("request to the API failed")
+	}
+	scrubbed := *urlErr
+	scrubbed.URL = scrubKey(u).String()
+	return &scrubbed
+}
+
+func buildResponse(response *response, location string) (*weather.Conditions, error) {
+	if err := response.Error(); err != nil {
+		switch {
+		case keyErrorMsg.MatchString(err.Error()):
+			return nil, keyError(err.Error())
+		case notFoundErrorMsg.MatchString(err.Error()):
+			return nil, &weather.NotFoundError{Location: location}
+		}
+		return nil, err
+	}
+	if err := response.complete(); err != nil {
+		return nil, err
 	}
 	return &weather.Conditions{
 		Celsius:     response.Celsius(),
 		Description: response.Description(),
+		Condition:   response.Condition(),
+		IsDay:       response.IsDay(),
+		WindKmph:    response.WindKmph(),
+		Alerts:      response.Alerts(),
 		Location:    response.Location(),
 	}, nil
 }
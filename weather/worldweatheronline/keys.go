@@ -0,0 +1,109 @@
+package worldweatheronline
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoKeyAvailable is returned when every API key is benched
+var ErrNoKeyAvailable = errors.New("no API key available")
+
+// KeyHealth reports how an API key has been doing since start. Keys are
+// identified by a hash so that they never show up in full.
+type KeyHealth struct {
+	Key          string
+	Calls        int
+	Failures     int
+	LastError    string
+	Benched      bool
+	BenchedUntil time.Time
+	Quota        *KeyUsage
+}
+
+// keyPool hands out API keys round-robin, skipping the benched ones
+type keyPool struct {
+	benchFor time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+}
+
+type poolKey struct {
+	key          string
+	calls        int
+	failures     int
+	lastError    string
+	benchedUntil time.Time
+}
+
+func newKeyPool(keys []string, benchFor time.Duration) *keyPool {
+	p := &keyPool{benchFor: benchFor, now: time.Now}
+	for _, k := range keys {
+		p.keys = append(p.keys, &poolKey{key: k})
+	}
+	return p
+}
+
+// take returns the next key that is neither benched nor rejected by
+// skip, or false if there is none
+func (p *keyPool) take(skip func(key string) bool) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for i := 0; i < len(p.keys); i++ {
+		k := p.keys[(p.next+i)%len(p.keys)]
+		if now.Before(k.benchedUntil) || skip(k.key) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.keys)
+		k.calls++
+		return k.key, true
+	}
+	return "", false
+}
+
+// failed records an error of the key and benches it when the error
+// means the key is unusable for now
+func (p *keyPool) failed(key string, err error, bench bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, k := range p.keys {
+		if k.key != key {
+			continue
+		}
+		k.failures++
+		k.lastError = err.Error()
+		if bench {
+			k.benchedUntil = p.now().Add(p.benchFor)
+		}
+	}
+}
+
+// health reports on every key, including the quota usage returned by
+// usage if it is not nil
+func (p *keyPool) health(usage func(key string) *KeyUsage) []KeyHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	health := make([]KeyHealth, len(p.keys))
+	for i, k := range p.keys {
+		health[i] = KeyHealth{
+			Key:          keyID(k.key),
+			Calls:        k.calls,
+			Failures:     k.failures,
+			LastError:    k.lastError,
+			Benched:      now.Before(k.benchedUntil),
+			BenchedUntil: k.benchedUntil,
+		}
+		if usage != nil {
+			health[i].Quota = usage(k.key)
+		}
+	}
+	return health
+}
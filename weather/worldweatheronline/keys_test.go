@@ -0,0 +1,161 @@
+package worldweatheronline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+const okResponse = `{"data":{
+	"request":[{"type":"City","query":"Berlin, Germany"}],
+	"current_condition":[{"temp_C":"12","weatherDesc":[{"value":"Sunny"}]}]
+}}`
+
+func testServer(handle func(key string, w http.ResponseWriter)) (calls *[]string, srv *httptest.Server) {
+	var keys []string
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		keys = append(keys, key)
+		handle(key, w)
+	}))
+	return &keys, srv
+}
+
+func TestClient_RoundRobin(t *testing.T) {
+	calls, srv := testServer(func(key string, w http.ResponseWriter) {
+		fmt.Fprint(w, okResponse)
+	})
+	defer srv.Close()
+
+	c := New([]string{"a", "b", "c"}, WithAPIURL(srv.URL))
+	for i := 0; i < 4; i++ {
+		if _, err := c.Forecast("Berlin"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if expected := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("expected keys to be used round-robin %v but got %v", expected, *calls)
+	}
+}
+
+func TestClient_BenchesFailingKeys(t *testing.T) {
+	calls, srv := testServer(func(key string, w http.ResponseWriter) {
+		switch key {
+		case "invalid":
+			fmt.Fprint(w, `{"data":{"error":[{"msg":"API key is invalid"}]}}`)
+		case "limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, okResponse)
+		}
+	})
+	defer srv.Close()
+
+	c := New([]string{"invalid", "limited", "good"}, WithAPIURL(srv.URL))
+	for i := 0; i < 3; i++ {
+		if conditions, err := c.Forecast("Berlin"); err != nil || conditions.Celsius != 12 {
+			t.Fatalf("expected the good key to answer but got %v, %v", conditions, err)
+		}
+	}
+
+	if expected := []string{"invalid", "limited", "good", "good", "good"}; !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("expected failing keys to be benched %v but got %v", expected, *calls)
+	}
+
+	health := c.Health()
+	if !health[0].Benched || !health[1].Benched || health[2].Benched {
+		t.Errorf("expected only the failing keys to be benched but got %v", health)
+	}
+	if health[0].LastError != "API responded with errors: API key is invalid" || health[2].Calls != 3 {
+		t.Errorf("unexpected health report %v", health)
+	}
+}
+
+func TestClient_RequestErrorsDontBench(t *testing.T) {
+	calls, srv := testServer(func(key string, w http.ResponseWriter) {
+		fmt.Fprint(w, `{"data":{"error":[{"msg":"Unable to find any matching weather location"}]}}`)
+	})
+	defer srv.Close()
+
+	c := New([]string{"a", "b"}, WithAPIURL(srv.URL))
+	if _, err := c.Forecast("nowhere"); err == nil {
+		t.Error("expected an error for an unknown location")
+	}
+	if len(*calls) != 1 || c.Health()[0].Benched {
+		t.Errorf("expected a single call without benching but got %v", *calls)
+	}
+}
+
+func TestClient_UpstreamFailures(t *testing.T) {
+	_, srv := testServer(func(key string, w http.ResponseWriter) {
+		switch key {
+		case "invalid":
+			fmt.Fprint(w, `{"data":{"error":[{"msg":"API key is invalid"}]}}`)
+		case "broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			fmt.Fprint(w, `{"data":{"error":[{"msg":"Parameter key 'dt' is not supported"}]}}`)
+		}
+	})
+	defer srv.Close()
+
+	for _, keys := range [][]string{{"invalid", "invalid"}, {"broken"}} {
+		_, err := New(keys, WithAPIURL(srv.URL)).Forecast("Berlin")
+		if _, ok := err.(*weather.UnavailableError); !ok {
+			t.Errorf("%v: expected the API to be unavailable but got %v", keys, err)
+		}
+	}
+
+	c := New([]string{"good"}, WithAPIURL(srv.URL))
+	c.Forecast("Berlin")
+	if c.Health()[0].Benched {
+		t.Error("expected errors mentioning a key, but not about the API key, to leave the key alone")
+	}
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestClient_TransportErrorsHideTheKey(t *testing.T) {
+	c := New([]string{"secret-key"}, WithTransport(failingTransport{}))
+	_, err := c.Forecast("Berlin")
+	if err == nil {
+		t.Fatal("expected an error of the transport")
+	}
+
+	lastError := c.Health()[0].LastError
+	for _, s := range []string{err.Error(), lastError} {
+		if strings.Contains(s, "secret-key") || !strings.Contains(s, "connection refused") {
+			t.Errorf("expected the error without the key but got %q", s)
+		}
+	}
+}
+
+func TestClient_QuotaExhaustedKeysAreSkipped(t *testing.T) {
+	calls, srv := testServer(func(key string, w http.ResponseWriter) {
+		fmt.Fprint(w, okResponse)
+	})
+	defer srv.Close()
+
+	q, _ := NewQuota("", 1, 1)
+	c := New([]string{"a", "b"}, WithAPIURL(srv.URL), WithQuota(q))
+
+	c.Forecast("Berlin")
+	c.Forecast("Berlin")
+	if _, err := c.Forecast("Berlin"); err != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded once all keys are spent but got %v", err)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("expected %v but got %v", expected, *calls)
+	}
+}
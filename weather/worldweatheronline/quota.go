@@ -0,0 +1,186 @@
+package worldweatheronline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned instead of calling the API once the
+// daily budget of a key is spent
+var ErrQuotaExceeded = errors.New("daily API quota exceeded")
+
+// Quota counts the calls made with each API key per day and refuses
+// further calls once a threshold of the daily limit is crossed. The
+// counters are persisted to a file so that they survive restarts.
+type Quota struct {
+	limit     int
+	threshold float64
+	path      string
+	now       func() time.Time
+
+	mu    sync.Mutex
+	day   string
+	calls map[string]int
+}
+
+// KeyUsage reports the calls made with a key on the current day. Keys
+// are identified by a hash so that they never show up in full.
+type KeyUsage struct {
+	Key       string
+	Used      int
+	Limit     int
+	Remaining int
+	Degraded  bool
+}
+
+type quotaFile struct {
+	Day   string         `json:"day"`
+	Calls map[string]int `json:"calls"`
+}
+
+// NewQuota returns a Quota allowing limit calls per key and day, that
+// stops calling the API once threshold (0..1) of the limit is used.
+// The counters are loaded from and saved to path unless it is empty.
+func NewQuota(path string, limit int, threshold float64) (*Quota, error) {
+	q := &Quota{
+		limit:     limit,
+		threshold: threshold,
+		path:      path,
+		now:       time.Now,
+		calls:     map[string]int{},
+	}
+	if path == "" {
+		return q, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var f quotaFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.Calls != nil {
+		q.day, q.calls = f.Day, f.Calls
+	}
+	return q, nil
+}
+
+// Spend accounts for a call with the key, or returns ErrQuotaExceeded
+// when the key has crossed the threshold for the day
+func (q *Quota) Spend(apiKey string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	id := keyID(apiKey)
+	if q.degraded(q.calls[id]) {
+		return ErrQuotaExceeded
+	}
+	q.calls[id]++
+	if err := q.save(); err != nil {
+		log.Printf("failed to save quota to %s: %s", q.path, err)
+	}
+	return nil
+}
+
+// Remaining returns the calls left for the key today
+func (q *Quota) Remaining(apiKey string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	return q.remaining(q.calls[keyID(apiKey)])
+}
+
+// Degraded reports whether calls with the key are refused for the
+// rest of the day
+func (q *Quota) Degraded(apiKey string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	return q.degraded(q.calls[keyID(apiKey)])
+}
+
+// UsageOf returns the usage of the key today
+func (q *Quota) UsageOf(apiKey string) KeyUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	used := q.calls[keyID(apiKey)]
+	return KeyUsage{
+		Key:       keyID(apiKey),
+		Used:      used,
+		Limit:     q.limit,
+		Remaining: q.remaining(used),
+		Degraded:  q.degraded(used),
+	}
+}
+
+func (q *Quota) remaining(used int) int {
+	if used >= q.limit {
+		return 0
+	}
+	return q.limit - used
+}
+
+func (q *Quota) degraded(used int) bool {
+	return float64(used) >= float64(q.limit)*q.threshold
+}
+
+// rollover resets the counters when the (UTC) day has changed
+func (q *Quota) rollover() {
+	today := q.now().UTC().Format("2006-01-02")
+	if q.day != today {
+		q.day = today
+		q.calls = map[string]int{}
+	}
+}
+
+// save writes the counters next to the quota file and renames it in
+// place so that a crash never leaves a half written file behind
+func (q *Quota) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(quotaFile{Day: q.day, Calls: q.calls})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
+
+// keyID identifies an API key without revealing it
+func keyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
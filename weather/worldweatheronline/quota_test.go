@@ -0,0 +1,76 @@
+package worldweatheronline
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuota_Spend(t *testing.T) {
+	q, _ := NewQuota("", 10, 0.5)
+
+	for i := 0; i < 5; i++ {
+		if err := q.Spend("key"); err != nil {
+			t.Fatalf("call %d was expected to be within budget but got %v", i, err)
+		}
+	}
+	if err := q.Spend("key"); err != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded after crossing the threshold but got %v", err)
+	}
+	if !q.Degraded("key") || q.Remaining("key") != 5 {
+		t.Errorf("expected key to be degraded with 5 calls remaining but got %v, %d", q.Degraded("key"), q.Remaining("key"))
+	}
+	if q.Degraded("other key") || q.Remaining("other key") != 10 {
+		t.Error("expected other keys to be unaffected")
+	}
+}
+
+func TestQuota_Rollover(t *testing.T) {
+	q, _ := NewQuota("", 1, 1)
+	now := time.Date(2018, 4, 18, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Spend("key")
+	if err := q.Spend("key"); err != ErrQuotaExceeded {
+		t.Errorf("expected ErrQuotaExceeded but got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := q.Spend("key"); err != nil {
+		t.Errorf("expected quota to be reset the next day but got %v", err)
+	}
+}
+
+func TestQuota_Persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "quota.json")
+
+	q, err := NewQuota(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Spend("key")
+	q.Spend("key")
+
+	b, _ := ioutil.ReadFile(path)
+	if len(b) == 0 {
+		t.Fatal("expected quota to be saved")
+	}
+
+	restarted, err := NewQuota(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := restarted.Remaining("key"); r != 8 {
+		t.Errorf("expected 8 remaining calls after restart but got %d", r)
+	}
+	if usage := restarted.UsageOf("key"); usage.Used != 2 || usage.Key == "key" {
+		t.Errorf("expected the usage of the hashed key but got %v", usage)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
 )
 
 type request string
@@ -15,6 +18,9 @@ func (r request) encodeWithDefaults(apiKey string) string {
 		"num_days": []string{"1"},
 		"key":      []string{apiKey},
 		"q":        []string{string(r)},
+		// the local time tells day from night, see response.IsDay
+		"showlocaltime": []string{"yes"},
+		"alerts":        []string{"yes"},
 	}
 	return u.Encode()
 }
@@ -38,6 +44,19 @@ func (r *response) Error() error {
 	return errors.New(errMsg)
 }
 
+// errIncompleteResponse is returned for responses without errors that
+// lack the request or the current conditions
+var errIncompleteResponse = errors.New("API responded without current conditions")
+
+// complete checks that the response has what the accessors below index
+// into, the accessors may only be called on complete responses
+func (r *response) complete() error {
+	if len(r.Data.RequestInfo) == 0 || len(r.Data.Conditions) == 0 || len(r.Data.Conditions[0].Description) == 0 {
+		return errIncompleteResponse
+	}
+	return nil
+}
+
 // Location returns the location query
 func (r *response) Location() string {
 	return strings.Join([]string{r.Data.RequestInfo[0].Type, r.Data.RequestInfo[0].Query}, " ")
@@ -54,12 +73,93 @@ func (r *response) Description() string {
 	return r.Data.Conditions[0].Description[0].Value
 }
 
+// Condition returns the kind of the current conditions
+func (r *response) Condition() weather.Condition {
+	return Condition(r.Data.Conditions[0].WeatherCode)
+}
+
+// WindKmph returns the current wind speed in km/h
+func (r *response) WindKmph() int {
+	kmph, _ := strconv.Atoi(r.Data.Conditions[0].WindKmph)
+	return kmph
+}
+
+// Alerts returns the weather alerts issued for the location. Times that
+// don't parse are left zero, i.e. from now on and until further notice.
+func (r *response) Alerts() []weather.Alert {
+	var alerts []weather.Alert
+	for _, a := range r.Data.Alerts.Alert {
+		severity, _ := weather.ParseSeverity(a.Severity)
+		start, _ := time.Parse(time.RFC3339, a.Effective)
+		end, _ := time.Parse(time.RFC3339, a.Expires)
+
+		text := a.Headline
+		if text == "" {
+			text = a.Event
+		}
+		alerts = append(alerts, weather.Alert{
+			Type:     alertType(a.Event),
+			Severity: severity,
+			Start:    start,
+			End:      end,
+			Text:     text,
+		})
+	}
+	return alerts
+}
+
+// alertType returns the type of the alert event, e.g. weather.WindAlert
+// for a "Severe gale warning", or the event itself
+func alertType(event string) weather.AlertType {
+	e := strings.ToLower(event)
+	switch {
+	case strings.Contains(e, "heat"):
+		return weather.HeatAlert
+	case strings.Contains(e, "frost"), strings.Contains(e, "freez"):
+		return weather.FrostAlert
+	case strings.Contains(e, "wind"), strings.Contains(e, "gale"), strings.Contains(e, "storm"):
+		return weather.WindAlert
+	}
+	return weather.AlertType(e)
+}
+
+// IsDay reports whether the sun is up at the location, or true if the
+// local time or the sunrise and sunset are missing, e.g. in polar regions
+// answering "No sunrise"
+func (r *response) IsDay() bool {
+	if len(r.Data.TimeZone) == 0 || len(r.Data.Weather) == 0 || len(r.Data.Weather[0].Astronomy) == 0 {
+		return true
+	}
+	now, err := time.Parse(localTimeLayout, r.Data.TimeZone[0].LocalTime)
+	if err != nil {
+		return true
+	}
+
+	day, astronomy := r.Data.Weather[0].Date, r.Data.Weather[0].Astronomy[0]
+	sunrise, riseErr := time.Parse(astronomyLayout, day+" "+astronomy.Sunrise)
+	sunset, setErr := time.Parse(astronomyLayout, day+" "+astronomy.Sunset)
+	if riseErr != nil || setErr != nil {
+		return true
+	}
+	return !now.Before(sunrise) && now.Before(sunset)
+}
+
+const (
+	localTimeLayout = "2006-01-02 15:04"
+	astronomyLayout = "2006-01-02 03:04 PM"
+)
+
 type data struct {
 	Error []struct {
 		Msg string `json:"msg"`
 	} `json:"error"`
 	RequestInfo []requestInfo `json:"request"`
 	Conditions  []conditions  `json:"current_condition"`
+	TimeZone    []timeZone    `json:"time_zone"`
+	Weather     []day         `json:"weather"`
+	Alerts      struct {
+		Alert []alert `json:"alert"`
+	} `json:"alerts"`
 }
 
 type requestInfo struct {
@@ -69,9 +169,34 @@ type requestInfo struct {
 
 type conditions struct {
 	TemperatureCelsius string         `json:"temp_C"`
+	WeatherCode        string         `json:"weatherCode"`
+	WindKmph           string         `json:"windspeedKmph"`
 	Description        []wrappedValue `json:"weatherDesc"`
 }
 
+// alert is a weather alert in the terms of the Common Alerting Protocol
+type alert struct {
+	Headline  string `json:"headline"`
+	Severity  string `json:"severity"`
+	Event     string `json:"event"`
+	Effective string `json:"effective"`
+	Expires   string `json:"expires"`
+}
+
+type timeZone struct {
+	LocalTime string `json:"localtime"`
+}
+
+type day struct {
+	Date      string      `json:"date"`
+	Astronomy []astronomy `json:"astronomy"`
+}
+
+type astronomy struct {
+	Sunrise string `json:"sunrise"`
+	Sunset  string `json:"sunset"`
+}
+
 type wrappedValue struct {
 	Value string `json:"value"`
 }
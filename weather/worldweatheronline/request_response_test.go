@@ -0,0 +1,83 @@
+package worldweatheronline
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/wwgberlin/go-weather-widget/weather"
+)
+
+func TestResponse_IsDay(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data     string
+		expected bool
+	}{
+		"noon":          {`"time_zone":[{"localtime":"2018-04-18 12:00"}],"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, true},
+		"at sunrise":    {`"time_zone":[{"localtime":"2018-04-18 06:04"}],"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, true},
+		"before dawn":   {`"time_zone":[{"localtime":"2018-04-18 05:59"}],"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, false},
+		"at sunset":     {`"time_zone":[{"localtime":"2018-04-18 20:08"}],"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, false},
+		"midnight":      {`"time_zone":[{"localtime":"2018-04-18 00:00"}],"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, false},
+		"no local time": {`"weather":[{"date":"2018-04-18","astronomy":[{"sunrise":"06:04 AM","sunset":"08:08 PM"}]}]`, true},
+		"no astronomy":  {`"time_zone":[{"localtime":"2018-04-18 00:00"}]`, true},
+		"polar":         {`"time_zone":[{"localtime":"2018-12-18 00:00"}],"weather":[{"date":"2018-12-18","astronomy":[{"sunrise":"No sunrise","sunset":"No sunset"}]}]`, true},
+	} {
+		var r response
+		if err := json.Unmarshal([]byte(`{"data":{`+tc.data+`}}`), &r); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if isDay := r.IsDay(); isDay != tc.expected {
+			t.Errorf("%s: expected IsDay to be %v", name, tc.expected)
+		}
+	}
+}
+
+func TestBuildResponse_Incomplete(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":          `{}`,
+		"no request":     `{"current_condition":[{"temp_C":"12","weatherDesc":[{"value":"Sunny"}]}]}`,
+		"no conditions":  `{"request":[{"type":"City","query":"Berlin, Germany"}],"current_condition":[]}`,
+		"no description": `{"request":[{"type":"City","query":"Berlin, Germany"}],"current_condition":[{"temp_C":"12"}]}`,
+	} {
+		var r response
+		if err := json.Unmarshal([]byte(`{"data":`+data+`}`), &r); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := buildResponse(&r, "Berlin"); err != errIncompleteResponse {
+			t.Errorf("%s: expected %v but got %v", name, errIncompleteResponse, err)
+		}
+	}
+}
+
+func TestResponse_Alerts(t *testing.T) {
+	var r response
+	err := json.Unmarshal([]byte(`{"data":{"alerts":{"alert":[
+		{"headline":"Severe gale warning until Friday","severity":"Severe","event":"Gale warning",
+		 "effective":"2018-04-18T05:00:00+00:00","expires":"2018-04-20T05:00:00+00:00"},
+		{"severity":"Unheard of","event":"Volcanic ash","effective":"soon"}
+	]}}}`), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []weather.Alert{
+		{
+			Type:     weather.WindAlert,
+			Severity: weather.Severe,
+			Start:    time.Date(2018, time.April, 18, 5, 0, 0, 0, time.UTC),
+			End:      time.Date(2018, time.April, 20, 5, 0, 0, 0, time.UTC),
+			Text:     "Severe gale warning until Friday",
+		},
+		{Type: "volcanic ash", Text: "Volcanic ash"},
+	}
+	alerts := r.Alerts()
+	if len(alerts) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, alerts)
+	}
+	for i, a := range alerts {
+		e := expected[i]
+		if a.Type != e.Type || a.Severity != e.Severity || !a.Start.Equal(e.Start) || !a.End.Equal(e.End) || a.Text != e.Text {
+			t.Errorf("expected %v but got %v", e, a)
+		}
+	}
+}